@@ -55,15 +55,14 @@ func main() {
 		}()
 	}
 
-	db, err := database.New(c.DatabaseConnectionURL)
-
+	db, err := chainRegistry(c)
 	if err != nil {
 		panic(err)
 	}
 
-	s, err := store.NewClient(c.RedisURL)
+	s, err := ticketStore(c)
 	if err != nil {
-		l.Panicw("unable to start redis client", "error", err)
+		l.Panicw("unable to start store", "backend", c.StoreBackend, "error", err)
 	}
 	var chains []cnsmodels.Chain
 
@@ -143,12 +142,20 @@ func main() {
 	}
 }
 
-func startNewWatcher(chainName string, chainsMap map[string]cnsmodels.Chain, config *rpcwatcher.Config, db *database.Instance, s *store.Store,
+func startNewWatcher(chainName string, chainsMap map[string]cnsmodels.Chain, config *rpcwatcher.Config, db rpcwatcher.ChainRegistry, s rpcwatcher.TicketStore,
 	l *zap.SugaredLogger, isNewChain bool) (map[string]cnsmodels.Chain, *rpcwatcher.Watcher, context.CancelFunc, bool) {
 	eventMappings := rpcwatcher.StandardMappings
 
 	grpcEndpoint := fmt.Sprintf("%s:%d", chainName, grpcPort)
 
+	if inChains(chainName, config.WasmChains) { // opt-in, tracks contract executions
+		eventMappings = rpcwatcher.WasmMappings
+	}
+
+	if inChains(chainName, config.OsmosisChains) { // opt-in, tracks gamm and poolmanager pools
+		eventMappings = rpcwatcher.OsmosisMappings
+	}
+
 	if chainName == "cosmos-hub" { // special case, needs to observe new blocks too
 		eventMappings = rpcwatcher.CosmosHubMappings
 
@@ -173,7 +180,7 @@ func startNewWatcher(chainName string, chainsMap map[string]cnsmodels.Chain, con
 			l.Errorw("cannot get node info", "error", err)
 		}
 
-		bz, err := s.Cdc.MarshalJSON(nodeInfoRes)
+		bz, err := s.Codec().MarshalJSON(nodeInfoRes)
 		if err != nil {
 			l.Errorw("cannot marshal node info", "error", err)
 		}
@@ -186,7 +193,7 @@ func startNewWatcher(chainName string, chainsMap map[string]cnsmodels.Chain, con
 
 	}
 
-	watcher, err := rpcwatcher.NewWatcher(endpoint(chainName), chainName, l, config.ApiURL, grpcEndpoint, db, s, rpcwatcher.EventsToSubTo, eventMappings)
+	watcher, err := rpcwatcher.NewWatcher(endpoint(chainName), chainName, l, grpcEndpoint, db, s, rpcwatcher.EventsToSubTo, eventMappings)
 
 	if err != nil {
 		if isNewChain {
@@ -203,6 +210,11 @@ func startNewWatcher(chainName string, chainsMap map[string]cnsmodels.Chain, con
 		return chainsMap, nil, nil, true
 	}
 
+	watcher.SetBlockRetention(config.BlockRetention)
+	watcher.SetSnapshots(config.ChainSnapshotQueries(chainName), config.SnapshotInterval)
+	watcher.SetLCDEndpoint(config.ChainLCDEndpoint(chainName))
+	watcher.SetDenomSync(config.DenomSyncInterval, config.DenomSyncDryRun)
+
 	err = s.SetWithExpiry(chainName, "true", 0)
 	if err != nil {
 		l.Errorw("unable to set chain name as true", "error", err)
@@ -216,6 +228,30 @@ func startNewWatcher(chainName string, chainsMap map[string]cnsmodels.Chain, con
 	return chainsMap, watcher, cancel, false
 }
 
+// chainRegistry returns the static chain registry when one is configured, CNS otherwise.
+func chainRegistry(c *rpcwatcher.Config) (rpcwatcher.ChainRegistry, error) {
+	if c.ChainRegistryFile != "" {
+		return database.NewFileRegistry(c.ChainRegistryFile)
+	}
+
+	return database.New(c.DatabaseConnectionURL)
+}
+
+// ticketStore returns the store configured to hold tickets and chain data, Redis unless the in-memory
+// one is asked for.
+func ticketStore(c *rpcwatcher.Config) (rpcwatcher.TicketStore, error) {
+	if c.StoreBackend == rpcwatcher.MemoryStoreBackend {
+		return rpcwatcher.NewMemoryStore(), nil
+	}
+
+	s, err := store.NewClient(c.RedisURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return rpcwatcher.NewRedisStore(s), nil
+}
+
 func mapChains(c []cnsmodels.Chain) map[string]cnsmodels.Chain {
 	ret := map[string]cnsmodels.Chain{}
 	for _, cc := range c {
@@ -228,3 +264,13 @@ func mapChains(c []cnsmodels.Chain) map[string]cnsmodels.Chain {
 func endpoint(chainName string) string {
 	return fmt.Sprintf("http://%s:26657", chainName)
 }
+
+func inChains(chainName string, chains []string) bool {
+	for _, c := range chains {
+		if c == chainName {
+			return true
+		}
+	}
+
+	return false
+}
@@ -101,8 +101,8 @@ func (s *IntegrationTestSuite) SetupSuite() {
 		if chain.chainID == "cosmos-hub" { // special case, needs to observe new blocks too
 			eventMappings = rpcwatcher.CosmosHubMappings
 		}
-		watcher, err := rpcwatcher.NewWatcher(getRPCAddress(chain.nodeAddress, defaultRPCPort), chain.chainID, logger, "",
-			getGRPCAddress(chain.nodeAddress, defaultGRPCPort), s.dbInstance, s.store, rpcwatcher.EventsToSubTo, eventMappings)
+		watcher, err := rpcwatcher.NewWatcher(getRPCAddress(chain.nodeAddress, defaultRPCPort), chain.chainID, logger,
+			getGRPCAddress(chain.nodeAddress, defaultGRPCPort), s.dbInstance, rpcwatcher.NewRedisStore(s.store), rpcwatcher.EventsToSubTo, eventMappings)
 		s.Require().NoError(err)
 
 		err = s.store.SetWithExpiry(chain.chainID, "true", 0)
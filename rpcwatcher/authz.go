@@ -0,0 +1,170 @@
+package rpcwatcher
+
+import (
+	"fmt"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const msgExecTypeURL = "/cosmos.authz.v1beta1.MsgExec"
+
+// TxAuthorization holds the parties of a transaction signed through an authz grant or paid through
+// a fee grant, as recorded on its ticket.
+type TxAuthorization struct {
+	Granter    string `json:"granter,omitempty"`
+	Grantee    string `json:"grantee,omitempty"`
+	FeeGranter string `json:"fee_granter,omitempty"`
+}
+
+// Empty returns true if the transaction wasn't signed nor paid through a grant.
+func (a TxAuthorization) Empty() bool {
+	return a == TxAuthorization{}
+}
+
+// wrappedTx is the content of a transaction once authz executions are unwrapped.
+type wrappedTx struct {
+	Authorization TxAuthorization
+	InnerMsgTypes []string
+}
+
+// decodeRawTx decodes the body and auth info of a transaction without resolving its messages, so that
+// transactions carrying messages unknown to the watcher codec can be inspected as well.
+func decodeRawTx(bz []byte) (txtypes.TxBody, txtypes.AuthInfo, error) {
+	var (
+		raw      txtypes.TxRaw
+		body     txtypes.TxBody
+		authInfo txtypes.AuthInfo
+	)
+
+	if len(bz) == 0 {
+		return body, authInfo, fmt.Errorf("empty tx")
+	}
+
+	if err := raw.Unmarshal(bz); err != nil {
+		return body, authInfo, fmt.Errorf("cannot decode tx, %w", err)
+	}
+
+	if err := body.Unmarshal(raw.BodyBytes); err != nil {
+		return body, authInfo, fmt.Errorf("cannot decode tx body, %w", err)
+	}
+
+	if err := authInfo.Unmarshal(raw.AuthInfoBytes); err != nil {
+		return body, authInfo, fmt.Errorf("cannot decode tx auth info, %w", err)
+	}
+
+	return body, authInfo, nil
+}
+
+// unwrapTx returns the messages executed on behalf of a granter by the authz MsgExec messages of
+// txBytes, along with the parties involved in authz and fee grants.
+// The granter isn't part of MsgExec, so it is read from the signer of the inner messages, which are
+// unpacked with cdc.
+func unwrapTx(cdc codectypes.AnyUnpacker, txBytes []byte) (wrappedTx, error) {
+	var w wrappedTx
+
+	body, authInfo, err := decodeRawTx(txBytes)
+	if err != nil {
+		return w, err
+	}
+
+	if authInfo.Fee != nil {
+		w.Authorization.FeeGranter = authInfo.Fee.Granter
+	}
+
+	for _, msg := range body.Messages {
+		if msg.TypeUrl != msgExecTypeURL {
+			continue
+		}
+
+		grantee, msgs, err := parseMsgExec(msg.Value)
+		if err != nil {
+			return w, err
+		}
+
+		w.Authorization.Grantee = grantee
+		for _, m := range msgs {
+			w.InnerMsgTypes = append(w.InnerMsgTypes, m.TypeUrl)
+
+			if w.Authorization.Granter == "" {
+				w.Authorization.Granter = msgSigner(cdc, m, grantee)
+			}
+		}
+	}
+
+	return w, nil
+}
+
+// msgSigner returns the first signer of msg, encoded with the bech32 prefix of grantee, or an empty
+// string if msg isn't known to cdc.
+func msgSigner(cdc codectypes.AnyUnpacker, msg *codectypes.Any, grantee string) string {
+	hrp, _, err := bech32.DecodeAndConvert(grantee)
+	if err != nil {
+		return ""
+	}
+
+	var sdkMsg sdktypes.Msg
+	if err := cdc.UnpackAny(msg, &sdkMsg); err != nil {
+		return ""
+	}
+
+	signers := sdkMsg.GetSigners()
+	if len(signers) == 0 {
+		return ""
+	}
+
+	signer, err := bech32.ConvertAndEncode(hrp, signers[0])
+	if err != nil {
+		return ""
+	}
+
+	return signer
+}
+
+// parseMsgExec returns the grantee and the messages held by a protobuf encoded authz MsgExec.
+func parseMsgExec(bz []byte) (string, []*codectypes.Any, error) {
+	var (
+		grantee string
+		msgs    []*codectypes.Any
+	)
+
+	for len(bz) > 0 {
+		num, typ, n := protowire.ConsumeTag(bz)
+		if n < 0 {
+			return "", nil, fmt.Errorf("cannot decode MsgExec, %w", protowire.ParseError(n))
+		}
+		bz = bz[n:]
+
+		if typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, bz)
+			if n < 0 {
+				return "", nil, fmt.Errorf("cannot decode MsgExec, %w", protowire.ParseError(n))
+			}
+			bz = bz[n:]
+			continue
+		}
+
+		v, n := protowire.ConsumeBytes(bz)
+		if n < 0 {
+			return "", nil, fmt.Errorf("cannot decode MsgExec, %w", protowire.ParseError(n))
+		}
+		bz = bz[n:]
+
+		switch num {
+		case 1:
+			grantee = string(v)
+		case 2:
+			var msg codectypes.Any
+			if err := msg.Unmarshal(v); err != nil {
+				return "", nil, fmt.Errorf("cannot decode MsgExec message, %w", err)
+			}
+
+			msgs = append(msgs, &msg)
+		}
+	}
+
+	return grantee, msgs, nil
+}
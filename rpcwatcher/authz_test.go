@@ -0,0 +1,180 @@
+package rpcwatcher
+
+import (
+	"testing"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	txtypes "github.com/cosmos/cosmos-sdk/types/tx"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/types"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	testGranter    = "cosmos1qymla9gh8z2cmrylt008hkre0gry6h92sxgazg"
+	testGrantee    = "cosmos14wgkjxfq2wy7qj8hyy4rlnzyvcx5k400zr9xpe"
+	testFeeGranter = "cosmos1apflgtl8chdz7yczr8964m8vrecxxdzzldgkvn"
+)
+
+// msgExecBytes returns a protobuf encoded authz MsgExec executing msgTypes on behalf of grantee.
+func msgExecBytes(grantee string, msgTypes ...string) []byte {
+	var bz []byte
+	bz = protowire.AppendTag(bz, 1, protowire.BytesType)
+	bz = protowire.AppendString(bz, grantee)
+
+	for _, msgType := range msgTypes {
+		msg := codectypes.Any{TypeUrl: msgType}
+		msgBytes, _ := msg.Marshal()
+
+		bz = protowire.AppendTag(bz, 2, protowire.BytesType)
+		bz = protowire.AppendBytes(bz, msgBytes)
+	}
+
+	return bz
+}
+
+// rawTxBytes returns a protobuf encoded transaction holding msgs and paid by feeGranter, if any.
+func rawTxBytes(t *testing.T, feeGranter string, msgs ...*codectypes.Any) []byte {
+	body := txtypes.TxBody{Messages: msgs, Memo: "test memo"}
+	bodyBytes, err := body.Marshal()
+	require.NoError(t, err)
+
+	authInfo := txtypes.AuthInfo{Fee: &txtypes.Fee{
+		Amount:   sdktypes.NewCoins(sdktypes.NewInt64Coin("uatom", 5000)),
+		GasLimit: 200000,
+		Granter:  feeGranter,
+	}}
+	authInfoBytes, err := authInfo.Marshal()
+	require.NoError(t, err)
+
+	raw := txtypes.TxRaw{BodyBytes: bodyBytes, AuthInfoBytes: authInfoBytes}
+	bz, err := raw.Marshal()
+	require.NoError(t, err)
+
+	return bz
+}
+
+func TestParseMsgExec(t *testing.T) {
+	tests := []struct {
+		name       string
+		bz         []byte
+		expGrantee string
+		expTypes   []string
+		expErr     bool
+	}{
+		{
+			"single message",
+			msgExecBytes(testGrantee, "/cosmos.bank.v1beta1.MsgSend"),
+			testGrantee,
+			[]string{"/cosmos.bank.v1beta1.MsgSend"},
+			false,
+		},
+		{
+			"multiple messages",
+			msgExecBytes(testGrantee, "/cosmos.staking.v1beta1.MsgDelegate", "/cosmos.gov.v1beta1.MsgVote"),
+			testGrantee,
+			[]string{"/cosmos.staking.v1beta1.MsgDelegate", "/cosmos.gov.v1beta1.MsgVote"},
+			false,
+		},
+		{
+			"truncated message",
+			msgExecBytes(testGrantee)[:5],
+			"",
+			nil,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grantee, msgs, err := parseMsgExec(tt.bz)
+			if tt.expErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expGrantee, grantee)
+
+			var msgTypes []string
+			for _, m := range msgs {
+				msgTypes = append(msgTypes, m.TypeUrl)
+			}
+			require.Equal(t, tt.expTypes, msgTypes)
+		})
+	}
+}
+
+func TestUnwrapTx(t *testing.T) {
+	sendMsg := &codectypes.Any{TypeUrl: "/cosmos.bank.v1beta1.MsgSend"}
+	unknownExecMsg := &codectypes.Any{
+		TypeUrl: msgExecTypeURL,
+		Value:   msgExecBytes(testGrantee, "/osmosis.gamm.v1beta1.MsgSwapExactAmountIn"),
+	}
+
+	tests := []struct {
+		name       string
+		txBytes    []byte
+		expWrapped wrappedTx
+		expErr     bool
+	}{
+		{
+			"plain tx",
+			rawTxBytes(t, "", sendMsg),
+			wrappedTx{},
+			false,
+		},
+		{
+			"fee granted tx",
+			rawTxBytes(t, testFeeGranter, sendMsg),
+			wrappedTx{Authorization: TxAuthorization{FeeGranter: testFeeGranter}},
+			false,
+		},
+		{
+			// the fee granter and the distribution module are message senders too, the granter is the
+			// delegator of the inner MsgDelegate
+			"authz exec with fee grant",
+			authzExecEvent(t).Data.(types.EventDataTx).Tx,
+			wrappedTx{
+				Authorization: TxAuthorization{Granter: testGranter, Grantee: testGrantee, FeeGranter: testFeeGranter},
+				InnerMsgTypes: []string{"/cosmos.staking.v1beta1.MsgDelegate"},
+			},
+			false,
+		},
+		{
+			"authz exec of messages unknown to the codec",
+			rawTxBytes(t, "", unknownExecMsg),
+			wrappedTx{
+				Authorization: TxAuthorization{Grantee: testGrantee},
+				InnerMsgTypes: []string{"/osmosis.gamm.v1beta1.MsgSwapExactAmountIn"},
+			},
+			false,
+		},
+		{
+			"empty tx",
+			nil,
+			wrappedTx{},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wrapped, err := unwrapTx(s.Cdc, tt.txBytes)
+			if tt.expErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expWrapped, wrapped)
+		})
+	}
+}
+
+func TestTypedActionAuthzExec(t *testing.T) {
+	txType, ok := typedAction(s.Cdc, authzExecEvent(t))
+	require.True(t, ok)
+	require.Equal(t, txTypeDelegation, txType)
+}
@@ -0,0 +1,273 @@
+package rpcwatcher
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/emerishq/emeris-utils/store"
+	liquiditytypes "github.com/gravity-devs/liquidity/x/liquidity/types"
+	abci "github.com/tendermint/tendermint/abci/types"
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+const (
+	batchedStatus = "batched"
+
+	batchKeyFmt       = "batch/%s/%s/%s/%s"
+	batchFailedErrFmt = "%s of pool %s rejected in batch %s"
+
+	// batched messages are indexed for one day (288 * defaultExpiry)
+	batchExpiryMul = 288
+)
+
+// batchResultEvents maps the liquidity messages executed in batches to the end-block event carrying
+// their outcome.
+var batchResultEvents = map[string]string{
+	liquiditytypes.EventTypeDepositWithinBatch:  liquiditytypes.EventTypeDepositToPool,
+	liquiditytypes.EventTypeWithdrawWithinBatch: liquiditytypes.EventTypeWithdrawFromPool,
+	liquiditytypes.EventTypeSwapWithinBatch:     liquiditytypes.EventTypeSwapTransacted,
+}
+
+// BatchResult describes the outcome of a liquidity message executed at the end of a Gravity DEX batch,
+// as recorded on its ticket.
+type BatchResult struct {
+	Type       string `json:"type"`
+	PoolID     string `json:"pool_id"`
+	BatchIndex string `json:"batch_index"`
+	MsgIndex   string `json:"msg_index"`
+	Success    bool   `json:"success"`
+	Accepted   string `json:"accepted,omitempty"`
+	Refunded   string `json:"refunded,omitempty"`
+	Fees       string `json:"fees,omitempty"`
+	PoolCoin   string `json:"pool_coin,omitempty"`
+}
+
+// batchedMsg identifies a liquidity message within a batch.
+type batchedMsg struct {
+	PoolID     string
+	BatchIndex string
+	MsgIndex   string
+}
+
+// key returns the key indexing m. Message indexes keep increasing across the batches of a pool, and
+// swap orders can span several batches, so the batch index isn't part of it.
+func (m batchedMsg) key(chainName, eventType string) string {
+	return fmt.Sprintf(batchKeyFmt, chainName, eventType, m.PoolID, m.MsgIndex)
+}
+
+// batchedMsgs returns the liquidity messages of type eventType appended to a batch by the
+// transaction carried by data.
+func batchedMsgs(data coretypes.ResultEvent, eventType string) []batchedMsg {
+	poolIDs := data.Events[eventType+"."+liquiditytypes.AttributeValuePoolId]
+	batchIndexes := data.Events[eventType+"."+liquiditytypes.AttributeValueBatchIndex]
+	msgIndexes := data.Events[eventType+"."+liquiditytypes.AttributeValueMsgIndex]
+
+	if len(poolIDs) != len(batchIndexes) || len(poolIDs) != len(msgIndexes) {
+		return nil
+	}
+
+	msgs := make([]batchedMsg, 0, len(poolIDs))
+	for i := range poolIDs {
+		msgs = append(msgs, batchedMsg{
+			PoolID:     poolIDs[i],
+			BatchIndex: batchIndexes[i],
+			MsgIndex:   msgIndexes[i],
+		})
+	}
+
+	return msgs
+}
+
+// setBatched marks the ticket stored at key as batched, and indexes the liquidity messages of type
+// eventType its transaction appended to a batch, so that the ticket can be resolved at batch end.
+// It returns false if no message could be indexed.
+func setBatched(w *Watcher, data coretypes.ResultEvent, chainName, key, eventType string, height int64) bool {
+	msgs := batchedMsgs(data, eventType)
+	if len(msgs) == 0 {
+		w.l.Errorw("batch index not found", "key", key, "event", eventType)
+		return false
+	}
+
+	if err := setTicketFields(w.store, key, struct {
+		Status string `json:"status"`
+		Height int64  `json:"height"`
+	}{batchedStatus, height}); err != nil {
+		w.l.Errorw("cannot set status as batched", "key", key, "error", err)
+		return false
+	}
+
+	for _, m := range msgs {
+		if err := w.store.SetWithExpiry(m.key(chainName, eventType), key, batchExpiryMul); err != nil {
+			w.l.Errorw("cannot index batched message", "key", key, "error", err)
+		}
+	}
+
+	w.recordTxInfo(key, data)
+	w.recordTransition(key, chainName, height, data.Events)
+
+	// the results of the block may have been handled before its transactions, in which case the
+	// messages just indexed are resolved right away
+	resolveCachedBatches(w, chainName, height)
+	return true
+}
+
+// HandleBatchedTransaction marks as batched the ticket of a Gravity DEX deposit or withdrawal,
+// which is resolved once its batch is executed.
+func HandleBatchedTransaction(w *Watcher, data coretypes.ResultEvent, chainName, key, eventType string, height int64) {
+	w.l.Debugw("called HandleBatchedTransaction", "event", eventType)
+	if !w.store.Exists(key) {
+		w.l.Debugw("bypassing key, event not sourced from us", "chain_name", w.Name, "key", key, "event", eventType)
+		return
+	}
+
+	if setBatched(w, data, chainName, key, eventType, height) {
+		return
+	}
+
+	if err := w.store.SetComplete(key, height); err != nil {
+		w.l.Errorw("cannot set complete", "chain name", chainName, "error", err)
+		return
+	}
+
+	w.recordTxInfo(key, data)
+	w.recordTransition(key, chainName, height, data.Events)
+}
+
+// blockResults holds the fields of a block_results RPC response needed to resolve batched tickets.
+type blockResults struct {
+	Result struct {
+		EndBlockEvents []abci.Event `json:"end_block_events"`
+	} `json:"result"`
+}
+
+// endBlockEvents returns the end-block events of the block_results response bz.
+func endBlockEvents(bz []byte) ([]abci.Event, error) {
+	var res blockResults
+	if err := json.Unmarshal(bz, &res); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal block results, %w", err)
+	}
+
+	return res.Result.EndBlockEvents, nil
+}
+
+// resolveBatches resolves the batched tickets whose message outcome is carried by the end-block
+// events of the block at height.
+func resolveBatches(w *Watcher, events []abci.Event, height int64) {
+	for msgType, resultType := range batchResultEvents {
+		for _, e := range events {
+			if e.Type != resultType {
+				continue
+			}
+
+			resolveBatchedMsg(w, msgType, e, height)
+		}
+	}
+}
+
+// resolveCachedBatches resolves the batched tickets whose message outcome is carried by the cached
+// results of the block at height, if any.
+func resolveCachedBatches(w *Watcher, chainName string, height int64) {
+	bz, err := w.store.BlockResults(chainName, height)
+	if err == store.ErrBlockNotFound {
+		return
+	}
+
+	if err != nil {
+		w.l.Errorw("cannot read cached block results", "chain_name", chainName, "height", height, "error", err)
+		return
+	}
+
+	events, err := endBlockEvents(bz)
+	if err != nil {
+		w.l.Errorw("cannot read end block events", "chain_name", chainName, "height", height, "error", err)
+		return
+	}
+
+	resolveBatches(w, events, height)
+}
+
+func resolveBatchedMsg(w *Watcher, msgType string, event abci.Event, height int64) {
+	attrs := map[string]string{}
+	events := map[string][]string{}
+	for _, a := range event.Attributes {
+		attrs[string(a.Key)] = string(a.Value)
+		events[event.Type+"."+string(a.Key)] = []string{string(a.Value)}
+	}
+
+	m := batchedMsg{
+		PoolID:     attrs[liquiditytypes.AttributeValuePoolId],
+		BatchIndex: attrs[liquiditytypes.AttributeValueBatchIndex],
+		MsgIndex:   attrs[liquiditytypes.AttributeValueMsgIndex],
+	}
+
+	batchKey := m.key(w.Name, msgType)
+	res, err := w.store.Value(batchKey)
+	if err == ErrKeyNotFound {
+		return
+	}
+
+	if err != nil {
+		w.l.Errorw("cannot read batched message", "key", batchKey, "error", err)
+		return
+	}
+
+	key := string(res)
+
+	if msgType == liquiditytypes.EventTypeSwapWithinBatch {
+		resolveSwap(w, key, batchKey, attrs, events, height)
+		return
+	}
+
+	r := batchResult(msgType, m, attrs)
+
+	err = keepTicketFields(w.store, key, func() error {
+		if r.Success {
+			return w.store.SetComplete(key, height)
+		}
+
+		return w.store.SetFailedWithErr(key, fmt.Sprintf(batchFailedErrFmt, msgType, m.PoolID, m.BatchIndex), height)
+	})
+	if err != nil {
+		w.l.Errorw("cannot resolve batched ticket", "key", key, "error", err)
+		return
+	}
+
+	if err := setTicketFields(w.store, key, struct {
+		Batch BatchResult `json:"batch"`
+	}{r}); err != nil {
+		w.l.Errorw("cannot set batch result on ticket", "key", key, "error", err)
+	}
+
+	w.recordTransition(key, w.Name, height, events)
+
+	if err := w.store.Delete(batchKey); err != nil {
+		w.l.Errorw("cannot delete batched message", "key", batchKey, "error", err)
+	}
+}
+
+// batchResult reads the amounts accepted, refunded and charged by the end-block event attributes
+// attrs of a deposit or withdrawal.
+func batchResult(msgType string, m batchedMsg, attrs map[string]string) BatchResult {
+	r := BatchResult{
+		Type:       msgType,
+		PoolID:     m.PoolID,
+		BatchIndex: m.BatchIndex,
+		MsgIndex:   m.MsgIndex,
+		Success:    attrs[liquiditytypes.AttributeValueSuccess] == liquiditytypes.Success,
+	}
+
+	if amount, ok := attrs[liquiditytypes.AttributeValuePoolCoinAmount]; ok {
+		r.PoolCoin = amount + attrs[liquiditytypes.AttributeValuePoolCoinDenom]
+	}
+
+	switch msgType {
+	case liquiditytypes.EventTypeDepositWithinBatch:
+		r.Accepted = attrs[liquiditytypes.AttributeValueAcceptedCoins]
+		r.Refunded = attrs[liquiditytypes.AttributeValueRefundedCoins]
+	case liquiditytypes.EventTypeWithdrawWithinBatch:
+		r.Accepted = attrs[liquiditytypes.AttributeValueWithdrawCoins]
+		r.Fees = attrs[liquiditytypes.AttributeValueWithdrawFeeCoins]
+	}
+
+	return r
+}
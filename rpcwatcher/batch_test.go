@@ -0,0 +1,208 @@
+package rpcwatcher
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/emerishq/emeris-rpcwatcher/rpcwatcher/database"
+	"github.com/emerishq/emeris-utils/store"
+	liquiditytypes "github.com/gravity-devs/liquidity/x/liquidity/types"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+// batchEvent returns an end-block event of type eventType carrying attrs.
+func batchEvent(eventType string, attrs map[string]string) abci.Event {
+	e := abci.Event{Type: eventType}
+	for k, v := range attrs {
+		e.Attributes = append(e.Attributes, abci.EventAttribute{Key: []byte(k), Value: []byte(v)})
+	}
+
+	return e
+}
+
+// blockResultsJSON returns a block_results RPC response holding the given end-block events.
+func blockResultsJSON(t *testing.T, events ...abci.Event) []byte {
+	var res blockResults
+	res.Result.EndBlockEvents = events
+	bz, err := json.Marshal(res)
+	require.NoError(t, err)
+
+	return bz
+}
+
+func TestEndBlockEvents(t *testing.T) {
+	event := batchEvent(liquiditytypes.EventTypeDepositToPool, map[string]string{"pool_id": "5"})
+
+	events, err := endBlockEvents(blockResultsJSON(t, event))
+	require.NoError(t, err)
+	require.Equal(t, []abci.Event{event}, events)
+
+	_, err = endBlockEvents([]byte("invalid"))
+	require.Error(t, err)
+}
+
+const depositGasUsed = 84512
+
+// depositEvent returns a transaction event depositing into pool 5 within batch 23941.
+func depositEvent(t *testing.T) coretypes.ResultEvent {
+	event := nonIBCTransferEvent(t, true)
+	event.Events["message.action"] = []string{liquiditytypes.TypeMsgDepositWithinBatch}
+	event.Events["deposit_within_batch.pool_id"] = []string{"5"}
+	event.Events["deposit_within_batch.batch_index"] = []string{"23941"}
+	event.Events["deposit_within_batch.msg_index"] = []string{"12"}
+	event.Events["deposit_within_batch.deposit_coins"] = []string{"1000uatom,2000uosmo"}
+
+	eventTx := event.Data.(types.EventDataTx)
+	eventTx.Result.GasUsed = depositGasUsed
+	event.Data = eventTx
+
+	return event
+}
+
+func TestHandleBatchedTransaction(t *testing.T) {
+	watcherInstance := &Watcher{
+		l:    logger,
+		d:    dbInstance,
+		Name: database.TestChainName,
+	}
+
+	tests := []struct {
+		name      string
+		data      coretypes.ResultEvent
+		results   []abci.Event
+		expStatus string
+		expBatch  *BatchResult
+	}{
+		{
+			"Handle deposit waiting for batch",
+			depositEvent(t),
+			nil,
+			batchedStatus,
+			nil,
+		},
+		{
+			"Handle deposit of another message",
+			depositEvent(t),
+			[]abci.Event{batchEvent(liquiditytypes.EventTypeDepositToPool, map[string]string{
+				"pool_id":     "5",
+				"batch_index": "23941",
+				"msg_index":   "13",
+				"success":     "success",
+			})},
+			batchedStatus,
+			nil,
+		},
+		{
+			"Handle accepted deposit",
+			depositEvent(t),
+			[]abci.Event{batchEvent(liquiditytypes.EventTypeDepositToPool, map[string]string{
+				"pool_id":          "5",
+				"batch_index":      "23941",
+				"msg_index":        "12",
+				"accepted_coins":   "1000uatom,1800uosmo",
+				"refunded_coins":   "200uosmo",
+				"pool_coin_denom":  defaultPoolDenom,
+				"pool_coin_amount": "42",
+				"success":          "success",
+			})},
+			"complete",
+			&BatchResult{
+				Type:       liquiditytypes.EventTypeDepositWithinBatch,
+				PoolID:     "5",
+				BatchIndex: "23941",
+				MsgIndex:   "12",
+				Success:    true,
+				Accepted:   "1000uatom,1800uosmo",
+				Refunded:   "200uosmo",
+				PoolCoin:   "42" + defaultPoolDenom,
+			},
+		},
+		{
+			"Handle rejected deposit",
+			depositEvent(t),
+			[]abci.Event{batchEvent(liquiditytypes.EventTypeDepositToPool, map[string]string{
+				"pool_id":        "5",
+				"batch_index":    "23941",
+				"msg_index":      "12",
+				"refunded_coins": "1000uatom,2000uosmo",
+				"success":        "failure",
+			})},
+			"failed",
+			&BatchResult{
+				Type:       liquiditytypes.EventTypeDepositWithinBatch,
+				PoolID:     "5",
+				BatchIndex: "23941",
+				MsgIndex:   "12",
+				Refunded:   "1000uatom,2000uosmo",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := NewMemoryStore()
+			watcherInstance.store = ms
+			require.NoError(t, ms.CreateTicket(watcherInstance.Name, nonIBCTransferTxHash, testOwner))
+			key := store.GetKey(database.TestChainName, nonIBCTransferTxHash)
+
+			HandleMessage(watcherInstance, tt.data)
+			resolveBatches(watcherInstance, tt.results, defaultHeight+1)
+
+			ticket, err := ms.Get(key)
+			require.NoError(t, err)
+			require.Equal(t, tt.expStatus, ticket.Status)
+
+			res, err := ms.Value(key)
+			require.NoError(t, err)
+
+			var fields struct {
+				Batch   *BatchResult `json:"batch"`
+				GasUsed int64        `json:"gas_used"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(res), &fields))
+			require.Equal(t, tt.expBatch, fields.Batch)
+			require.Equal(t, int64(depositGasUsed), fields.GasUsed)
+		})
+	}
+}
+
+func TestHandleBatchedTransactionAfterBlock(t *testing.T) {
+	ms := NewMemoryStore()
+	watcherInstance := &Watcher{
+		l:              logger,
+		d:              dbInstance,
+		store:          ms,
+		Name:           database.TestChainName,
+		blockRetention: defaultBlockRetention,
+	}
+
+	data := depositEvent(t)
+	eventTx := data.Data.(types.EventDataTx)
+	eventTx.Height = defaultHeight
+	data.Data = eventTx
+
+	require.NoError(t, ms.CreateTicket(watcherInstance.Name, nonIBCTransferTxHash, testOwner))
+	key := store.GetKey(database.TestChainName, nonIBCTransferTxHash)
+
+	// the block carrying the batch outcome is handled before the transaction appending to it
+	events := []abci.Event{batchEvent(liquiditytypes.EventTypeDepositToPool, map[string]string{
+		"pool_id":     "5",
+		"batch_index": "23941",
+		"msg_index":   "12",
+		"success":     "success",
+	})}
+	cacheBlockResults(watcherInstance, blockResultsJSON(t, events...), defaultHeight)
+	resolveBatches(watcherInstance, events, defaultHeight)
+
+	HandleMessage(watcherInstance, data)
+
+	ticket, err := ms.Get(key)
+	require.NoError(t, err)
+	require.Equal(t, "complete", ticket.Status)
+
+	_, err = watcherInstance.store.Value(batchedMsg{PoolID: "5", MsgIndex: "12"}.key(watcherInstance.Name, liquiditytypes.EventTypeDepositWithinBatch))
+	require.Equal(t, ErrKeyNotFound, err)
+}
@@ -0,0 +1,145 @@
+package rpcwatcher
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+const (
+	blockResultsKeyFmt      = "block_results/%s/%s"
+	blockResultsIndexKeyFmt = "block_results/%s"
+
+	// defaultBlockRetention is the number of most recent block results kept for each chain
+	defaultBlockRetention = 100
+
+	defaultRPCClientTimeout          = 5 * time.Second
+	defaultBlockResultsRetryInterval = 250 * time.Millisecond
+
+	// defaultBlockResultsDeadline bounds the time spent waiting for the node to index the results of a block
+	defaultBlockResultsDeadline = 30 * time.Second
+)
+
+func blockResultsKey(chainName, height string) string {
+	return fmt.Sprintf(blockResultsKeyFmt, chainName, height)
+}
+
+// SetBlockRetention sets the number of most recent block results cached for the watched chain,
+// a zero value disables caching.
+func (w *Watcher) SetBlockRetention(heights int64) {
+	w.blockRetention = heights
+}
+
+// HandleBlockResults caches the results of every new block of the watched chain, without blocking
+// the handling of the following events.
+func HandleBlockResults(w *Watcher, data coretypes.ResultEvent) {
+	realData, ok := data.Data.(types.EventDataNewBlock)
+	if !ok {
+		panic("rpc returned block data which is not of expected type")
+	}
+
+	if realData.Block == nil || w.blockRetention <= 0 {
+		return
+	}
+
+	height := realData.Block.Height
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultBlockResultsDeadline)
+		defer cancel()
+
+		bz, err := awaitBlockResults(ctx, w, height)
+		if err != nil {
+			w.l.Errorw("cannot query node for block data", "chain_name", w.Name, "error", err, "height", height)
+			return
+		}
+
+		cacheBlockResults(w, bz, height)
+	}()
+}
+
+// newRPCClient returns the HTTP client used to query the Tendermint RPC and LCD endpoints of a chain.
+func newRPCClient() *http.Client {
+	return &http.Client{
+		Timeout: defaultRPCClientTimeout,
+	}
+}
+
+// awaitBlockResults queries the block_results of the watched chain at height until the node has indexed
+// them, or ctx is done.
+func awaitBlockResults(ctx context.Context, w *Watcher, height int64) ([]byte, error) {
+	for {
+		bz, err := fetchBlockResults(w, height)
+		if err == nil {
+			return bz, nil
+		}
+
+		w.l.Debugw("block results not available yet", "chain_name", w.Name, "height", height, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("block results at height %d not available, %w", height, err)
+		case <-time.After(defaultBlockResultsRetryInterval):
+		}
+	}
+}
+
+// fetchBlockResults queries the block_results RPC endpoint of the watched chain at height.
+func fetchBlockResults(w *Watcher, height int64) ([]byte, error) {
+	ru, err := url.Parse(w.endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse url %s, %w", w.endpoint, err)
+	}
+
+	vals := url.Values{}
+	vals.Set("height", strconv.FormatInt(height, 10))
+
+	w.l.Debugw("asking for block", "chain_name", w.Name, "height", height)
+
+	ru.Path = "block_results"
+	ru.RawQuery = vals.Encode()
+
+	resp, err := w.rpcClient.Get(ru.String())
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("endpoint returned non-200 code %d", resp.StatusCode)
+	}
+
+	res := bytes.Buffer{}
+	read, err := res.ReadFrom(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read block data resp body into buffer, %w", err)
+	}
+
+	if read == 0 {
+		return nil, fmt.Errorf("read zero bytes from response body")
+	}
+
+	return res.Bytes(), nil
+}
+
+// cacheBlockResults stores the block_results response bz of the watched chain at height, and prunes
+// the results older than the last w.blockRetention heights.
+func cacheBlockResults(w *Watcher, bz []byte, height int64) {
+	if w.blockRetention <= 0 {
+		return
+	}
+
+	if err := w.store.SetBlockResults(w.Name, height, bz, w.blockRetention); err != nil {
+		w.l.Errorw("cannot cache block results", "chain_name", w.Name, "height", height, "error", err)
+	}
+}
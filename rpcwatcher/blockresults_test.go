@@ -0,0 +1,216 @@
+package rpcwatcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/emerishq/emeris-rpcwatcher/rpcwatcher/database"
+	"github.com/emerishq/emeris-utils/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheBlockResults(t *testing.T) {
+	tests := []struct {
+		name       string
+		retention  int64
+		heights    []int64
+		expHeights []int64
+	}{
+		{
+			"within retention",
+			3,
+			[]int64{10, 11},
+			[]int64{10, 11},
+		},
+		{
+			"older heights pruned",
+			3,
+			[]int64{10, 11, 12, 13, 14},
+			[]int64{12, 13, 14},
+		},
+		{
+			"out of order heights",
+			2,
+			[]int64{12, 10, 11},
+			[]int64{11, 12},
+		},
+		{
+			"caching disabled",
+			0,
+			[]int64{10, 11},
+			[]int64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer store.ResetTestStore(mr, s)
+
+			watcherInstance := &Watcher{
+				l:              logger,
+				store:          NewRedisStore(s),
+				Name:           database.TestChainName,
+				blockRetention: tt.retention,
+			}
+
+			for _, h := range tt.heights {
+				cacheBlockResults(watcherInstance, []byte(fmt.Sprintf(`{"height":"%d"}`, h)), h)
+			}
+
+			heights, err := watcherInstance.store.BlockResultsHeights(database.TestChainName)
+			require.NoError(t, err)
+			require.Equal(t, tt.expHeights, heights)
+
+			for _, h := range tt.heights {
+				bz, err := watcherInstance.store.BlockResults(database.TestChainName, h)
+				if !containsHeight(tt.expHeights, h) {
+					require.ErrorIs(t, err, store.ErrBlockNotFound)
+					continue
+				}
+
+				require.NoError(t, err)
+				require.Equal(t, fmt.Sprintf(`{"height":"%d"}`, h), string(bz))
+			}
+		})
+	}
+}
+
+func TestFetchBlockResults(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		expBody string
+		expErr  bool
+	}{
+		{
+			"block results available",
+			http.StatusOK,
+			`{"height":"42"}`,
+			`{"height":"42"}`,
+			false,
+		},
+		{
+			"non-200 response",
+			http.StatusInternalServerError,
+			`{"error":"height not available"}`,
+			"",
+			true,
+		},
+		{
+			"empty response",
+			http.StatusOK,
+			"",
+			"",
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/block_results", r.URL.Path)
+				require.Equal(t, "42", r.URL.Query().Get("height"))
+
+				rw.WriteHeader(tt.status)
+				_, _ = rw.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			watcherInstance := &Watcher{
+				l:         logger,
+				Name:      database.TestChainName,
+				endpoint:  srv.URL,
+				rpcClient: srv.Client(),
+			}
+
+			bz, err := fetchBlockResults(watcherInstance, 42)
+			if tt.expErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expBody, string(bz))
+		})
+	}
+}
+
+func TestAwaitBlockResults(t *testing.T) {
+	tests := []struct {
+		name        string
+		unavailable int
+		deadline    time.Duration
+		expErr      bool
+	}{
+		{
+			"available right away",
+			0,
+			time.Second,
+			false,
+		},
+		{
+			"available once indexed",
+			2,
+			time.Second,
+			false,
+		},
+		{
+			"deadline exceeded",
+			100,
+			2 * defaultBlockResultsRetryInterval,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				calls++
+				if calls <= tt.unavailable {
+					rw.WriteHeader(http.StatusInternalServerError)
+					_, _ = rw.Write([]byte(`{"error":"height 42 must be less than or equal to the current blockchain height 41"}`))
+					return
+				}
+
+				_, _ = rw.Write([]byte(`{"height":"42"}`))
+			}))
+			defer srv.Close()
+
+			watcherInstance := &Watcher{
+				l:         logger,
+				Name:      database.TestChainName,
+				endpoint:  srv.URL,
+				rpcClient: srv.Client(),
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.deadline)
+			defer cancel()
+
+			bz, err := awaitBlockResults(ctx, watcherInstance, 42)
+			if tt.expErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, `{"height":"42"}`, string(bz))
+			require.Equal(t, tt.unavailable+1, calls)
+		})
+	}
+}
+
+func containsHeight(heights []int64, height int64) bool {
+	for _, h := range heights {
+		if h == height {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,40 +1,94 @@
 package rpcwatcher
 
 import (
+	"fmt"
+	"strconv"
+
 	"github.com/emerishq/demeris-backend-models/validation"
 	"github.com/emerishq/emeris-utils/configuration"
 	"github.com/go-playground/validator/v10"
 )
 
 const (
+	// RedisStoreBackend keeps tickets and chain data in Redis, where the other services read them.
+	RedisStoreBackend = "redis"
+	// MemoryStoreBackend keeps tickets and chain data in memory, see MemoryStore.
+	MemoryStoreBackend = "memory"
+
 	defaultRedisURL           = "redis-master:6379"
-	defaultApiURL             = "http://api-server:8000"
 	defaultProfilingServerURL = "localhost:6060"
+	defaultSnapshotQueries    = "cosmos-hub:liquidity_pools,cosmos-hub:liquidity_params,cosmos-hub:supply"
 )
 
 type Config struct {
-	DatabaseConnectionURL string `validate:"required"`
-	RedisURL              string `validate:"required,hostname_port"`
-	ApiURL                string `validate:"required,url"`
+	DatabaseConnectionURL string `validate:"required_without=ChainRegistryFile"`
+	ChainRegistryFile     string
+	StoreBackend          string `validate:"oneof=redis memory"`
+	RedisURL              string `validate:"required_if=StoreBackend redis,omitempty,hostname_port"`
 	ProfilingServerURL    string `validate:"hostname_port"`
+	WasmChains            []string
+	OsmosisChains         []string
+	LCDEndpoints          []string
+	BlockRetention        int64 `validate:"gte=0"`
+	SnapshotQueries       []string
+	SnapshotInterval      int64 `validate:"gte=1"`
+	DenomSyncInterval     int64 `validate:"gte=0"`
+	DenomSyncDryRun       bool
 	Debug                 bool
 	JSONLogs              bool
 }
 
 func (c *Config) Validate() error {
 	err := validator.New().Struct(c)
-	if err == nil {
+	if err != nil {
+		return validation.MissingFieldsErr(err, false)
+	}
+
+	if _, err := parseSnapshotQueries(c.SnapshotQueries); err != nil {
+		return err
+	}
+
+	// each chain is watched with a single set of handlers
+	for _, wc := range c.WasmChains {
+		for _, oc := range c.OsmosisChains {
+			if wc == oc {
+				return fmt.Errorf("chain %s cannot be both in WasmChains and OsmosisChains", wc)
+			}
+		}
+	}
+
+	_, err = parseLCDEndpoints(c.LCDEndpoints)
+	return err
+}
+
+// ChainSnapshotQueries returns the snapshot queries configured for chainName.
+func (c *Config) ChainSnapshotQueries(chainName string) []string {
+	queries, err := parseSnapshotQueries(c.SnapshotQueries)
+	if err != nil {
 		return nil
 	}
 
-	return validation.MissingFieldsErr(err, false)
+	return queries[chainName]
+}
+
+// ChainLCDEndpoint returns the LCD endpoint configured for chainName, if any.
+func (c *Config) ChainLCDEndpoint(chainName string) string {
+	endpoints, err := parseLCDEndpoints(c.LCDEndpoints)
+	if err != nil {
+		return ""
+	}
+
+	return endpoints[chainName]
 }
 
 func ReadConfig() (*Config, error) {
 	var c Config
 	return &c, configuration.ReadConfig(&c, "rpcwatcher", map[string]string{
+		"StoreBackend":       RedisStoreBackend,
 		"RedisURL":           defaultRedisURL,
-		"ApiURL":             defaultApiURL,
 		"ProfilingServerURL": defaultProfilingServerURL,
+		"BlockRetention":     strconv.Itoa(defaultBlockRetention),
+		"SnapshotQueries":    defaultSnapshotQueries,
+		"SnapshotInterval":   strconv.Itoa(defaultSnapshotInterval),
 	})
 }
@@ -25,9 +25,12 @@ func TestReadConfig(t *testing.T) {
 			"config with default values : missing db connection url",
 			map[string]string{},
 			&Config{
+				StoreBackend:       RedisStoreBackend,
 				RedisURL:           defaultRedisURL,
-				ApiURL:             defaultApiURL,
 				ProfilingServerURL: defaultProfilingServerURL,
+				BlockRetention:     defaultBlockRetention,
+				SnapshotQueries:    strings.Split(defaultSnapshotQueries, ","),
+				SnapshotInterval:   defaultSnapshotInterval,
 			},
 			true,
 		},
@@ -39,39 +42,101 @@ func TestReadConfig(t *testing.T) {
 			},
 			&Config{
 				DatabaseConnectionURL: testDBURL,
+				StoreBackend:          RedisStoreBackend,
 				RedisURL:              "http://redis-server:1234",
-				ApiURL:                defaultApiURL,
 				ProfilingServerURL:    defaultProfilingServerURL,
+				BlockRetention:        defaultBlockRetention,
+				SnapshotQueries:       strings.Split(defaultSnapshotQueries, ","),
+				SnapshotInterval:      defaultSnapshotInterval,
 			},
 			true,
 		},
 		{
-			"set env with invalid api url",
+			"set env with invalid profiling server url",
 			map[string]string{
 				"DatabaseConnectionURL": testDBURL,
-				"ApiURL":                "0.0.0.0:3456",
+				"ProfilingServerURL":    "http://profiling-server:1234",
 			},
 			&Config{
 				DatabaseConnectionURL: testDBURL,
+				StoreBackend:          RedisStoreBackend,
 				RedisURL:              defaultRedisURL,
-				ApiURL:                "0.0.0.0:3456",
-				ProfilingServerURL:    defaultProfilingServerURL,
+				ProfilingServerURL:    "http://profiling-server:1234",
+				BlockRetention:        defaultBlockRetention,
+				SnapshotQueries:       strings.Split(defaultSnapshotQueries, ","),
+				SnapshotInterval:      defaultSnapshotInterval,
 			},
 			true,
 		},
 		{
-			"set env with invalid profiling server url",
+			"set env with unknown snapshot query",
 			map[string]string{
 				"DatabaseConnectionURL": testDBURL,
-				"ProfilingServerURL":    "http://profiling-server:1234",
+				"SnapshotQueries":       "cosmos-hub:unknown",
+			},
+			nil,
+			true,
+		},
+		{
+			"set env with invalid lcd endpoint",
+			map[string]string{
+				"DatabaseConnectionURL": testDBURL,
+				"LCDEndpoints":          "osmosis-lcd:1317",
+			},
+			nil,
+			true,
+		},
+		{
+			"valid config with chain registry file in place of db connection url",
+			map[string]string{
+				"ChainRegistryFile": "chains.yaml",
+			},
+			&Config{
+				ChainRegistryFile:  "chains.yaml",
+				StoreBackend:       RedisStoreBackend,
+				RedisURL:           defaultRedisURL,
+				ProfilingServerURL: defaultProfilingServerURL,
+				BlockRetention:     defaultBlockRetention,
+				SnapshotQueries:    strings.Split(defaultSnapshotQueries, ","),
+				SnapshotInterval:   defaultSnapshotInterval,
+			},
+			false,
+		},
+		{
+			"set env with chain in both wasm and osmosis chains",
+			map[string]string{
+				"DatabaseConnectionURL": testDBURL,
+				"WasmChains":            "juno,osmosis",
+				"OsmosisChains":         "osmosis",
+			},
+			nil,
+			true,
+		},
+		{
+			"set env with unknown store backend",
+			map[string]string{
+				"DatabaseConnectionURL": testDBURL,
+				"StoreBackend":          "etcd",
+			},
+			nil,
+			true,
+		},
+		{
+			"valid config with memory store backend",
+			map[string]string{
+				"DatabaseConnectionURL": testDBURL,
+				"StoreBackend":          MemoryStoreBackend,
 			},
 			&Config{
 				DatabaseConnectionURL: testDBURL,
+				StoreBackend:          MemoryStoreBackend,
 				RedisURL:              defaultRedisURL,
-				ApiURL:                defaultApiURL,
-				ProfilingServerURL:    "http://profiling-server:1234",
+				ProfilingServerURL:    defaultProfilingServerURL,
+				BlockRetention:        defaultBlockRetention,
+				SnapshotQueries:       strings.Split(defaultSnapshotQueries, ","),
+				SnapshotInterval:      defaultSnapshotInterval,
 			},
-			true,
+			false,
 		},
 		{
 			"valid config with default values",
@@ -80,9 +145,12 @@ func TestReadConfig(t *testing.T) {
 			},
 			&Config{
 				DatabaseConnectionURL: testDBURL,
+				StoreBackend:          RedisStoreBackend,
 				RedisURL:              defaultRedisURL,
-				ApiURL:                defaultApiURL,
 				ProfilingServerURL:    defaultProfilingServerURL,
+				BlockRetention:        defaultBlockRetention,
+				SnapshotQueries:       strings.Split(defaultSnapshotQueries, ","),
+				SnapshotInterval:      defaultSnapshotInterval,
 				Debug:                 false,
 				JSONLogs:              false,
 			},
@@ -93,18 +161,32 @@ func TestReadConfig(t *testing.T) {
 			map[string]string{
 				"DatabaseConnectionURL": testDBURL,
 				"RedisURL":              "0.0.0.0:6379",
-				"ApiURL":                "http://0.0.0.0:8080",
 				"ProfilingServerURL":    ":7777",
 				"Debug":                 "true",
 				"JSONLogs":              "true",
+				"WasmChains":            "juno,stargaze",
+				"OsmosisChains":         "osmosis",
+				"LCDEndpoints":          "osmosis=http://osmosis-lcd:1317",
+				"BlockRetention":        "250",
+				"SnapshotInterval":      "10",
+				"DenomSyncInterval":     "600",
+				"DenomSyncDryRun":       "true",
 			},
 			&Config{
 				DatabaseConnectionURL: testDBURL,
+				StoreBackend:          RedisStoreBackend,
 				RedisURL:              "0.0.0.0:6379",
-				ApiURL:                "http://0.0.0.0:8080",
 				ProfilingServerURL:    ":7777",
+				BlockRetention:        250,
+				SnapshotQueries:       strings.Split(defaultSnapshotQueries, ","),
+				SnapshotInterval:      10,
+				DenomSyncInterval:     600,
+				DenomSyncDryRun:       true,
 				Debug:                 true,
 				JSONLogs:              true,
+				WasmChains:            []string{"juno", "stargaze"},
+				OsmosisChains:         []string{"osmosis"},
+				LCDEndpoints:          []string{"osmosis=http://osmosis-lcd:1317"},
 			},
 			false,
 		},
@@ -0,0 +1,134 @@
+package rpcwatcher
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	channeltypes "github.com/cosmos/cosmos-sdk/x/ibc/core/04-channel/types"
+	ibctmtypes "github.com/cosmos/cosmos-sdk/x/ibc/light-clients/07-tendermint/types"
+	"github.com/gogo/protobuf/proto"
+	"google.golang.org/grpc"
+)
+
+const (
+	transferPort = "transfer"
+
+	counterpartyKeyFmt = "counterparty/%s/%s/%s"
+
+	// discovered counterparties are cached for one hour (12 * defaultExpiry)
+	counterpartyExpiryMul = 12
+	// channels whose counterparty cannot be found are not looked up again for a minute
+	counterpartyMissExpiry = time.Minute
+)
+
+// counterparty returns the name of the chain sitting on the other end of the given port and channel
+// of chainName.
+// CNS primary channels are looked up first, then the IBC client state backing the channel is queried
+// over gRPC and its chain ID mapped back to a CNS chain: results of the latter are cached in the store,
+// along with the channels whose counterparty cannot be found.
+func (w *Watcher) counterparty(chainName, port, channel string) (string, error) {
+	cacheKey := fmt.Sprintf(counterpartyKeyFmt, chainName, port, channel)
+	cached, err := w.store.Value(cacheKey)
+	switch {
+	case err == nil && len(cached) == 0:
+		return "", fmt.Errorf("no counterparty found for channel %s of port %s", channel, port)
+	case err != nil && err != ErrKeyNotFound:
+		w.l.Errorw("cannot read counterparty from cache", "key", cacheKey, "error", err)
+	}
+
+	c, dbErr := w.d.GetCounterParty(chainName, channel)
+	if dbErr == nil {
+		return c[0].Counterparty, nil
+	}
+
+	if err == nil {
+		return string(cached), nil
+	}
+
+	w.l.Debugw("no primary channel found, querying channel client state", "chain_name", chainName,
+		"port", port, "channel", channel, "error", dbErr)
+
+	counterparty, err := w.queryCounterparty(port, channel)
+	if err != nil {
+		if err := w.store.SetWithExpiryTime(cacheKey, "", counterpartyMissExpiry); err != nil {
+			w.l.Errorw("cannot cache counterparty miss", "key", cacheKey, "error", err)
+		}
+
+		return "", fmt.Errorf("%s, %w", dbErr, err)
+	}
+
+	if err := w.store.SetWithExpiry(cacheKey, counterparty, counterpartyExpiryMul); err != nil {
+		w.l.Errorw("cannot cache counterparty", "key", cacheKey, "error", err)
+	}
+
+	return counterparty, nil
+}
+
+// queryCounterparty resolves the counterparty chain of port and channel by querying the client state
+// of the channel on the watched chain.
+func (w *Watcher) queryCounterparty(port, channel string) (string, error) {
+	grpcConn, err := grpc.Dial(
+		w.grpcEndpoint,
+		grpc.WithInsecure(),
+	)
+	if err != nil {
+		return "", fmt.Errorf("cannot create gRPC client, %w", err)
+	}
+
+	defer func() {
+		if err := grpcConn.Close(); err != nil {
+			w.l.Errorw("cannot close gRPC client", "error", err, "chain_name", w.Name)
+		}
+	}()
+
+	channelQuery := channeltypes.NewQueryClient(grpcConn)
+	res, err := channelQuery.ChannelClientState(context.Background(), &channeltypes.QueryChannelClientStateRequest{
+		PortId:    port,
+		ChannelId: channel,
+	})
+	if err != nil {
+		return "", fmt.Errorf("cannot query client state of channel %s, %w", channel, err)
+	}
+
+	if res.IdentifiedClientState == nil {
+		return "", fmt.Errorf("no client state found for channel %s", channel)
+	}
+
+	chainID, err := clientStateChainID(res.IdentifiedClientState.ClientState)
+	if err != nil {
+		return "", err
+	}
+
+	chains, err := w.d.Chains()
+	if err != nil {
+		return "", err
+	}
+
+	for _, c := range chains {
+		if c.NodeInfo.ChainID == chainID {
+			return c.ChainName, nil
+		}
+	}
+
+	return "", fmt.Errorf("no chain found with chain id %s", chainID)
+}
+
+// clientStateChainID returns the chain ID tracked by a tendermint light client state.
+func clientStateChainID(clientState *codectypes.Any) (string, error) {
+	if clientState == nil {
+		return "", fmt.Errorf("empty client state")
+	}
+
+	if typeURL := "/" + proto.MessageName(&ibctmtypes.ClientState{}); clientState.TypeUrl != typeURL {
+		return "", fmt.Errorf("unsupported client state type %s", clientState.TypeUrl)
+	}
+
+	var cs ibctmtypes.ClientState
+	if err := cs.Unmarshal(clientState.Value); err != nil {
+		return "", fmt.Errorf("cannot unmarshal client state, %w", err)
+	}
+
+	return cs.ChainId, nil
+}
@@ -0,0 +1,115 @@
+package rpcwatcher
+
+import (
+	"fmt"
+	"testing"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	ibctmtypes "github.com/cosmos/cosmos-sdk/x/ibc/light-clients/07-tendermint/types"
+	"github.com/emerishq/emeris-rpcwatcher/rpcwatcher/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientStateChainID(t *testing.T) {
+	tmClientState, err := codectypes.NewAnyWithValue(&ibctmtypes.ClientState{ChainId: "akash-testnet"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		clientState *codectypes.Any
+		expChainID  string
+		expErr      bool
+	}{
+		{
+			"empty client state",
+			nil,
+			"",
+			true,
+		},
+		{
+			"unsupported client state",
+			&codectypes.Any{TypeUrl: "/ibc.lightclients.solomachine.v1.ClientState"},
+			"",
+			true,
+		},
+		{
+			"tendermint client state",
+			tmClientState,
+			"akash-testnet",
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chainID, err := clientStateChainID(tt.clientState)
+			if tt.expErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expChainID, chainID)
+		})
+	}
+}
+
+func TestCounterparty(t *testing.T) {
+	ms := NewMemoryStore()
+	watcherInstance := &Watcher{
+		l:     logger,
+		d:     dbInstance,
+		store: ms,
+		Name:  database.TestChainName,
+	}
+
+	require.NoError(t, ms.SetWithExpiry(fmt.Sprintf(counterpartyKeyFmt, database.TestChainName, transferPort, "channel-9"),
+		"osmosis", counterpartyExpiryMul))
+
+	tests := []struct {
+		name            string
+		channel         string
+		expCounterparty string
+		expErr          bool
+	}{
+		{
+			"counterparty from primary channel",
+			"channel-1",
+			"akash",
+			false,
+		},
+		{
+			"counterparty from cache",
+			"channel-9",
+			"osmosis",
+			false,
+		},
+		{
+			"unknown counterparty",
+			"channel-10",
+			"",
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counterparty, err := watcherInstance.counterparty(database.TestChainName, transferPort, tt.channel)
+			if tt.expErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expCounterparty, counterparty)
+		})
+	}
+	// unknown counterparties aren't looked up again until their miss expires
+	missKey := fmt.Sprintf(counterpartyKeyFmt, database.TestChainName, transferPort, "channel-10")
+	cached, err := ms.Value(missKey)
+	require.NoError(t, err)
+	require.Empty(t, cached)
+
+	_, err = watcherInstance.counterparty(database.TestChainName, transferPort, "channel-10")
+	require.Error(t, err)
+}
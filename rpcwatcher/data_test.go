@@ -3,9 +3,11 @@ package rpcwatcher
 import (
 	"encoding/json"
 	"io/ioutil"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
 	coretypes "github.com/tendermint/tendermint/rpc/core/types"
 	"github.com/tendermint/tendermint/types"
 )
@@ -22,6 +24,8 @@ const (
 	newPoolDenom = "pool96EF6EA6E5AC828ED87E8D07E7AE2A8180570ADD212117B2DA6F0B75D17A6294"
 
 	testOwner = "cosmos1vaa40n5naka7mav3za6kx40jckx6aa4nqvvx8a"
+
+	defaultAckError = "invalid receiver address"
 )
 
 var (
@@ -127,6 +131,28 @@ func nonIBCTransferEvent(t *testing.T, codeZero bool) coretypes.ResultEvent {
 	return event
 }
 
+// authzExecEvent returns a MsgExec delegating on behalf of testGranter, signed by testGrantee and paid
+// through a fee grant of testFeeGranter.
+func authzExecEvent(t *testing.T) coretypes.ResultEvent {
+	data, err := ioutil.ReadFile("./testdata/authz-exec-feegrant-tx.json")
+	require.NoError(t, err)
+	event := txJSONToResultEvent(t, data)
+
+	// the tx bytes are needed to unwrap the MsgExec
+	var b struct {
+		Result struct {
+			Data struct {
+				Value struct {
+					TxResult abci.TxResult
+				} `json:"value"`
+			} `json:"data"`
+		} `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(data, &b))
+	event.Data = types.EventDataTx{TxResult: b.Result.Data.Value.TxResult}
+	return event
+}
+
 func swapTransactionEvent(t *testing.T) coretypes.ResultEvent {
 	data, err := ioutil.ReadFile("./testdata/swap-tx.json")
 	require.NoError(t, err)
@@ -151,3 +177,23 @@ func createPoolEvent(t *testing.T, newDenom bool) coretypes.ResultEvent {
 	createPoolTxHash = txHashSlice[0]
 	return event
 }
+
+// asNFTTransferEvent rewrites the packet ports of a fungible IBC transfer event to the ICS-721 port,
+// and renames fungible token packet events to their non fungible counterpart.
+func asNFTTransferEvent(event coretypes.ResultEvent) coretypes.ResultEvent {
+	events := make(map[string][]string, len(event.Events))
+	for k, v := range event.Events {
+		if strings.HasPrefix(k, "fungible_token_packet.") {
+			k = "non_" + k
+		}
+
+		if strings.HasSuffix(k, "_port") && len(v) > 0 && v[0] == "transfer" {
+			v = []string{nftTransferPort}
+		}
+
+		events[k] = v
+	}
+
+	event.Events = events
+	return event
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"sync"
+
+	cnsmodels "github.com/emerishq/demeris-backend-models/cns"
+)
+
+// chainsCache holds the CNS chains looked up by the watchers, so that handling an event doesn't cost a
+// database round trip.
+// It's refreshed each time all the chains are read, and entries are dropped when updated through Instance.
+type chainsCache struct {
+	mu     sync.RWMutex
+	chains map[string]cnsmodels.Chain
+}
+
+func (c *chainsCache) get(chainName string) (cnsmodels.Chain, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	chain, ok := c.chains[chainName]
+	if !ok {
+		return cnsmodels.Chain{}, false
+	}
+
+	return copyChain(chain), true
+}
+
+func (c *chainsCache) set(chain cnsmodels.Chain) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.chains == nil {
+		c.chains = map[string]cnsmodels.Chain{}
+	}
+
+	c.chains[chain.ChainName] = copyChain(chain)
+}
+
+func (c *chainsCache) reset(chains []cnsmodels.Chain) {
+	fresh := make(map[string]cnsmodels.Chain, len(chains))
+	for _, chain := range chains {
+		fresh[chain.ChainName] = copyChain(chain)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.chains = fresh
+}
+
+func (c *chainsCache) invalidate(chainName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.chains, chainName)
+}
+
+// copyChain returns a copy of chain which doesn't share its denoms and primary channels, since callers
+// modify them.
+func copyChain(chain cnsmodels.Chain) cnsmodels.Chain {
+	if chain.Denoms != nil {
+		chain.Denoms = append(cnsmodels.DenomList{}, chain.Denoms...)
+	}
+
+	if chain.PrimaryChannel != nil {
+		primaryChannel := make(cnsmodels.DbStringMap, len(chain.PrimaryChannel))
+		for k, v := range chain.PrimaryChannel {
+			primaryChannel[k] = v
+		}
+
+		chain.PrimaryChannel = primaryChannel
+	}
+
+	return chain
+}
@@ -1,8 +1,12 @@
 package database
 
 import (
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
 
 	"github.com/cockroachdb/cockroach-go/v2/testserver"
 	cnsmodels "github.com/emerishq/demeris-backend-models/cns"
@@ -14,6 +18,7 @@ import (
 type Instance struct {
 	d          *dbutils.Instance
 	connString string
+	cache      chainsCache
 }
 
 func New(connString string) (*Instance, error) {
@@ -51,6 +56,8 @@ func (i *Instance) UpdateDenoms(chain cnsmodels.Chain) error {
 		return err
 	}
 
+	i.cache.invalidate(chain.ChainName)
+
 	rows, _ := res.RowsAffected()
 
 	if rows == 0 {
@@ -60,26 +67,190 @@ func (i *Instance) UpdateDenoms(chain cnsmodels.Chain) error {
 	return nil
 }
 
-func (i *Instance) Chain(chain string) (cnsmodels.Chain, error) {
-	var c cnsmodels.Chain
+// maxDenomsUpdateAttempts bounds the number of times a denoms update is retried after a concurrent update.
+const maxDenomsUpdateAttempts = 10
+
+// serializationFailureCode is the SQLSTATE of transactions aborted because of a concurrent transaction.
+const serializationFailureCode = "40001"
 
-	err := i.d.DB.Get(&c, fmt.Sprintf("SELECT * FROM cns.chains WHERE chain_name='%s' limit 1;", chain))
+// ErrDenomsConflict is returned when the denoms of a chain kept being concurrently updated.
+var ErrDenomsConflict = errors.New("denoms concurrently updated")
 
-	return c, err
+// UpsertDenom adds denom to the denoms of chainName, or replaces the denom with the same name.
+func (i *Instance) UpsertDenom(chainName string, denom cnsmodels.Denom) error {
+	bz, err := json.Marshal(denom)
+	if err != nil {
+		return err
+	}
+
+	return i.updateDenoms(chainName, func(denoms []json.RawMessage) ([]json.RawMessage, bool, error) {
+		for idx, d := range denoms {
+			name, err := denomName(d)
+			if err != nil {
+				return nil, false, err
+			}
+
+			if name == denom.Name {
+				denoms[idx] = bz
+				return denoms, true, nil
+			}
+		}
+
+		return append(denoms, bz), true, nil
+	})
 }
 
-func (i *Instance) Chains() ([]cnsmodels.Chain, error) {
-	var c []cnsmodels.Chain
+// AddDenoms adds to the denoms of chainName those of denoms it doesn't list yet, leaving the existing
+// ones untouched.
+func (i *Instance) AddDenoms(chainName string, denoms ...cnsmodels.Denom) error {
+	return i.updateDenoms(chainName, func(existing []json.RawMessage) ([]json.RawMessage, bool, error) {
+		known := map[string]bool{}
+		for _, d := range existing {
+			name, err := denomName(d)
+			if err != nil {
+				return nil, false, err
+			}
 
-	return c, i.d.Exec("SELECT * FROM cns.chains", nil, &c)
+			known[name] = true
+		}
+
+		changed := false
+		for _, d := range denoms {
+			if known[d.Name] {
+				continue
+			}
+
+			bz, err := json.Marshal(d)
+			if err != nil {
+				return nil, false, err
+			}
+
+			existing = append(existing, bz)
+			known[d.Name] = true
+			changed = true
+		}
+
+		return existing, changed, nil
+	})
 }
 
-func (i *Instance) GetCounterParty(chain, srcChannel string) ([]cnsmodels.ChannelQuery, error) {
-	var c []cnsmodels.ChannelQuery
+// updateDenoms applies update to the denoms of chainName with optimistic concurrency: the denoms are
+// only written if they weren't updated since they were read, otherwise update is applied again on the
+// fresh denoms.
+// Denoms are handled as raw JSON, so that fields unknown to cnsmodels.Denom are preserved.
+func (i *Instance) updateDenoms(chainName string, update func([]json.RawMessage) ([]json.RawMessage, bool, error)) error {
+	defer i.cache.invalidate(chainName)
+
+	for attempt := 0; attempt < maxDenomsUpdateAttempts; attempt++ {
+		done, err := i.tryUpdateDenoms(chainName, update)
+		if err != nil {
+			return err
+		}
+
+		if done {
+			return nil
+		}
+	}
 
-	q, err := i.d.DB.PrepareNamed("select chain_name, json_data.* from cns.chains, jsonb_each_text(primary_channel) as json_data where chain_name=:chain_name and value=:channel limit 1;")
+	return fmt.Errorf("cannot update denoms of chain %s, %w", chainName, ErrDenomsConflict)
+}
+
+// tryUpdateDenoms applies update to the denoms of chainName in a transaction, and returns false if they
+// were concurrently updated.
+func (i *Instance) tryUpdateDenoms(chainName string, update func([]json.RawMessage) ([]json.RawMessage, bool, error)) (bool, error) {
+	tx, err := i.d.DB.Beginx()
 	if err != nil {
-		return []cnsmodels.ChannelQuery{}, err
+		return false, err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var raw string
+	err = tx.Get(&raw, "SELECT denoms FROM cns.chains WHERE chain_name=$1;", chainName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, fmt.Errorf("chain %s not found", chainName)
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	var denoms []json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &denoms); err != nil {
+		return false, fmt.Errorf("cannot unmarshal denoms of chain %s, %w", chainName, err)
+	}
+
+	denoms, changed, err := update(denoms)
+	if err != nil {
+		return false, err
+	}
+
+	if !changed {
+		return true, nil
+	}
+
+	bz, err := json.Marshal(denoms)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := tx.Exec("UPDATE cns.chains SET denoms=$1::JSONB WHERE chain_name=$2 AND denoms=$3::JSONB;", string(bz), chainName, raw)
+	if isSerializationFailure(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if rows == 0 {
+		return false, nil
+	}
+
+	err = tx.Commit()
+	if isSerializationFailure(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// isSerializationFailure returns true when err aborted a transaction because of a concurrent one.
+func isSerializationFailure(err error) bool {
+	var sqlErr interface{ SQLState() string }
+	return errors.As(err, &sqlErr) && sqlErr.SQLState() == serializationFailureCode
+}
+
+func denomName(denom json.RawMessage) (string, error) {
+	var d struct {
+		Name string `json:"name"`
+	}
+
+	if err := json.Unmarshal(denom, &d); err != nil {
+		return "", fmt.Errorf("cannot unmarshal denom, %w", err)
+	}
+
+	return d.Name, nil
+}
+
+// Chain returns the CNS chain named chain, from the cache when possible.
+func (i *Instance) Chain(chain string) (cnsmodels.Chain, error) {
+	if c, ok := i.cache.get(chain); ok {
+		return c, nil
+	}
+
+	var c cnsmodels.Chain
+
+	q, err := i.d.DB.PrepareNamed("SELECT * FROM cns.chains WHERE chain_name=:chain_name LIMIT 1;")
+	if err != nil {
+		return c, err
 	}
 
 	defer func() {
@@ -89,20 +260,66 @@ func (i *Instance) GetCounterParty(chain, srcChannel string) ([]cnsmodels.Channe
 		}
 	}()
 
-	if err := q.Select(&c, map[string]interface{}{
+	if err := q.Get(&c, map[string]interface{}{
 		"chain_name": chain,
-		"channel":    srcChannel,
 	}); err != nil {
-		return []cnsmodels.ChannelQuery{}, err
+		return c, err
 	}
 
-	if len(c) == 0 {
-		return nil, fmt.Errorf("no counterparty found for chain %s on channel %s", chain, srcChannel)
+	i.cache.set(c)
+
+	return c, nil
+}
+
+// Chains returns all the CNS chains, and refreshes the cache with them so that changes made to CNS are
+// picked up.
+func (i *Instance) Chains() ([]cnsmodels.Chain, error) {
+	var c []cnsmodels.Chain
+
+	if err := i.d.Exec("SELECT * FROM cns.chains", nil, &c); err != nil {
+		return c, err
 	}
 
+	i.cache.reset(c)
+
 	return c, nil
 }
 
+// GetCounterParty returns the chain whose primary channel on chain is srcChannel.
+func (i *Instance) GetCounterParty(chain, srcChannel string) ([]cnsmodels.ChannelQuery, error) {
+	c, err := i.Chain(chain)
+	if err != nil {
+		return []cnsmodels.ChannelQuery{}, err
+	}
+
+	return counterparty(c, srcChannel)
+}
+
+// counterparty returns the chain whose primary channel on c is srcChannel.
+func counterparty(c cnsmodels.Chain, srcChannel string) ([]cnsmodels.ChannelQuery, error) {
+	// a misconfigured CNS may list the same channel for several chains, always pick the same one
+	var counterparties []string
+	for counterparty, channel := range c.PrimaryChannel {
+		if channel == srcChannel {
+			counterparties = append(counterparties, counterparty)
+		}
+	}
+
+	if len(counterparties) == 0 {
+		return nil, fmt.Errorf("no counterparty found for chain %s on channel %s", c.ChainName, srcChannel)
+	}
+
+	sort.Strings(counterparties)
+
+	return []cnsmodels.ChannelQuery{
+		{
+			ChainName:    c.ChainName,
+			Counterparty: counterparties[0],
+			ChannelName:  srcChannel,
+		},
+	}, nil
+}
+
 func SetupTestDB(migrations []string) (testserver.TestServer, *Instance) {
 	// start new cockroachDB test server
 	ts, err := testserver.NewTestServer()
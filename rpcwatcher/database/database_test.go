@@ -1,6 +1,7 @@
 package database
 
 import (
+	"fmt"
 	"os"
 	"testing"
 
@@ -42,6 +43,11 @@ func TestChain(t *testing.T) {
 			"invalid",
 			true,
 		},
+		{
+			"Get chain details with quoted chain name",
+			"invalid' OR '1'='1",
+			true,
+		},
 		{
 			"Get chain details with valid chain name",
 			TestChainName,
@@ -62,6 +68,47 @@ func TestChain(t *testing.T) {
 	}
 }
 
+func TestChainCache(t *testing.T) {
+	chain, err := dbInstance.Chain(TestChainName)
+	require.NoError(t, err)
+
+	// modifying a returned chain doesn't modify the cached one
+	chain.Denoms[0].DisplayName = "modified"
+	chain.PrimaryChannel["modified"] = "channel-42"
+
+	cached, err := dbInstance.Chain(TestChainName)
+	require.NoError(t, err)
+	require.NotEqual(t, "modified", cached.Denoms[0].DisplayName)
+	require.NotContains(t, cached.PrimaryChannel, "modified")
+
+	// changes made to CNS outside of the instance are picked up once all the chains are read again
+	_, err = dbInstance.d.DB.Exec("UPDATE cns.chains SET display_name=$1 WHERE chain_name=$2;", "Cached Hub", TestChainName)
+	require.NoError(t, err)
+
+	cached, err = dbInstance.Chain(TestChainName)
+	require.NoError(t, err)
+	require.Equal(t, chain.DisplayName, cached.DisplayName)
+
+	_, err = dbInstance.Chains()
+	require.NoError(t, err)
+
+	cached, err = dbInstance.Chain(TestChainName)
+	require.NoError(t, err)
+	require.Equal(t, "Cached Hub", cached.DisplayName)
+
+	// changes made through the instance are picked up right away
+	require.NoError(t, dbInstance.UpsertDenom(TestChainName, cnsmodels.Denom{Name: "ucached"}))
+
+	cached, err = dbInstance.Chain(TestChainName)
+	require.NoError(t, err)
+	require.Equal(t, "ucached", cached.Denoms[len(cached.Denoms)-1].Name)
+
+	_, err = dbInstance.d.DB.Exec("UPDATE cns.chains SET display_name=$1 WHERE chain_name=$2;", chain.DisplayName, TestChainName)
+	require.NoError(t, err)
+	_, err = dbInstance.Chains()
+	require.NoError(t, err)
+}
+
 func TestGetCounterParty(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -168,3 +215,95 @@ func TestUpdateDenoms(t *testing.T) {
 		})
 	}
 }
+
+func TestUpsertDenom(t *testing.T) {
+	tests := []struct {
+		name      string
+		chainName string
+		denom     cnsmodels.Denom
+		expErr    bool
+	}{
+		{
+			"Upsert denom of invalid chain",
+			"invalid",
+			cnsmodels.Denom{Name: "uupsert"},
+			true,
+		},
+		{
+			"Upsert new denom",
+			TestChainName,
+			cnsmodels.Denom{Name: "uupsert", DisplayName: "Upsert"},
+			false,
+		},
+		{
+			"Upsert existing denom",
+			TestChainName,
+			cnsmodels.Denom{Name: "uupsert", DisplayName: "Upserted", Verified: true},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, err := dbInstance.Chain(TestChainName)
+			require.NoError(t, err)
+
+			err = dbInstance.UpsertDenom(tt.chainName, tt.denom)
+			if tt.expErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+
+			after, err := dbInstance.Chain(TestChainName)
+			require.NoError(t, err)
+
+			count := 0
+			for _, d := range after.Denoms {
+				if d.Name == tt.denom.Name {
+					require.Equal(t, tt.denom, d)
+					count++
+				}
+			}
+			require.Equal(t, 1, count)
+			require.GreaterOrEqual(t, len(after.Denoms), len(before.Denoms))
+		})
+	}
+
+	// fields unknown to cnsmodels.Denom are preserved
+	var raw string
+	require.NoError(t, dbInstance.d.DB.Get(&raw, "SELECT denoms FROM cns.chains WHERE chain_name=$1;", TestChainName))
+	require.Contains(t, raw, "fee_levels")
+}
+
+func TestAddDenoms(t *testing.T) {
+	const concurrentUpdates = 5
+
+	errs := make(chan error, concurrentUpdates)
+	for n := 0; n < concurrentUpdates; n++ {
+		go func(n int) {
+			errs <- dbInstance.AddDenoms(TestChainName, cnsmodels.Denom{Name: fmt.Sprintf("uconcurrent%d", n)})
+		}(n)
+	}
+
+	for n := 0; n < concurrentUpdates; n++ {
+		require.NoError(t, <-errs)
+	}
+
+	// existing denoms are left untouched
+	require.NoError(t, dbInstance.AddDenoms(TestChainName, cnsmodels.Denom{Name: "uatom", DisplayName: "changed"}))
+
+	chain, err := dbInstance.Chain(TestChainName)
+	require.NoError(t, err)
+
+	found := map[string]cnsmodels.Denom{}
+	for _, d := range chain.Denoms {
+		found[d.Name] = d
+	}
+
+	for n := 0; n < concurrentUpdates; n++ {
+		require.Contains(t, found, fmt.Sprintf("uconcurrent%d", n))
+	}
+	require.Equal(t, "ATOM", found["uatom"].DisplayName)
+}
@@ -0,0 +1,165 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	cnsmodels "github.com/emerishq/demeris-backend-models/cns"
+	"gopkg.in/yaml.v3"
+)
+
+// FileRegistry is a static chain registry read from a YAML or JSON file, in place of CNS.
+// Denoms updates are kept in memory and never written back to the file.
+type FileRegistry struct {
+	mu     sync.RWMutex
+	chains map[string]cnsmodels.Chain
+}
+
+// registryFile is the layout of a chain registry file, whose chains use the field names of the CNS
+// JSON representation.
+type registryFile struct {
+	Chains []interface{} `yaml:"chains"`
+}
+
+// NewFileRegistry returns a FileRegistry holding the chains listed in the file at path.
+func NewFileRegistry(path string) (*FileRegistry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read chain registry, %w", err)
+	}
+
+	return NewRegistry(data)
+}
+
+// NewRegistry returns a FileRegistry holding the chains listed in data.
+func NewRegistry(data []byte) (*FileRegistry, error) {
+	var f registryFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal chain registry, %w", err)
+	}
+
+	// going through JSON reuses the decoding of cnsmodels.Chain fields, such as thresholds
+	bz, err := json.Marshal(f.Chains)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal chain registry, %w", err)
+	}
+
+	var chains []cnsmodels.Chain
+	if err := json.Unmarshal(bz, &chains); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal chain registry chains, %w", err)
+	}
+
+	r := &FileRegistry{
+		chains: make(map[string]cnsmodels.Chain, len(chains)),
+	}
+
+	for idx, c := range chains {
+		if c.ChainName == "" {
+			return nil, fmt.Errorf("chain %d of chain registry has no name", idx)
+		}
+
+		if _, ok := r.chains[c.ChainName]; ok {
+			return nil, fmt.Errorf("chain %s listed twice in chain registry", c.ChainName)
+		}
+
+		r.chains[c.ChainName] = c
+	}
+
+	return r, nil
+}
+
+func (r *FileRegistry) Chain(chain string) (cnsmodels.Chain, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.chains[chain]
+	if !ok {
+		return cnsmodels.Chain{}, fmt.Errorf("chain %s not found", chain)
+	}
+
+	return copyChain(c), nil
+}
+
+// Chains returns the chains of the registry, sorted by name.
+func (r *FileRegistry) Chains() ([]cnsmodels.Chain, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	chains := make([]cnsmodels.Chain, 0, len(r.chains))
+	for _, c := range r.chains {
+		chains = append(chains, copyChain(c))
+	}
+
+	sort.Slice(chains, func(i, j int) bool { return chains[i].ChainName < chains[j].ChainName })
+
+	return chains, nil
+}
+
+func (r *FileRegistry) GetCounterParty(chain, srcChannel string) ([]cnsmodels.ChannelQuery, error) {
+	c, err := r.Chain(chain)
+	if err != nil {
+		return []cnsmodels.ChannelQuery{}, err
+	}
+
+	return counterparty(c, srcChannel)
+}
+
+func (r *FileRegistry) UpdateDenoms(chain cnsmodels.Chain) error {
+	return r.updateDenoms(chain.ChainName, func(cnsmodels.DenomList) cnsmodels.DenomList {
+		return append(cnsmodels.DenomList{}, chain.Denoms...)
+	})
+}
+
+// UpsertDenom adds denom to the denoms of chainName, or replaces the denom with the same name.
+func (r *FileRegistry) UpsertDenom(chainName string, denom cnsmodels.Denom) error {
+	return r.updateDenoms(chainName, func(denoms cnsmodels.DenomList) cnsmodels.DenomList {
+		for idx, d := range denoms {
+			if d.Name == denom.Name {
+				denoms[idx] = denom
+				return denoms
+			}
+		}
+
+		return append(denoms, denom)
+	})
+}
+
+// AddDenoms adds to the denoms of chainName those of denoms it doesn't list yet, leaving the existing
+// ones untouched.
+func (r *FileRegistry) AddDenoms(chainName string, denoms ...cnsmodels.Denom) error {
+	return r.updateDenoms(chainName, func(existing cnsmodels.DenomList) cnsmodels.DenomList {
+		known := map[string]bool{}
+		for _, d := range existing {
+			known[d.Name] = true
+		}
+
+		for _, d := range denoms {
+			if known[d.Name] {
+				continue
+			}
+
+			existing = append(existing, d)
+			known[d.Name] = true
+		}
+
+		return existing
+	})
+}
+
+func (r *FileRegistry) updateDenoms(chainName string, update func(cnsmodels.DenomList) cnsmodels.DenomList) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.chains[chainName]
+	if !ok {
+		return fmt.Errorf("chain %s not found", chainName)
+	}
+
+	c.Denoms = update(append(cnsmodels.DenomList{}, c.Denoms...))
+	r.chains[chainName] = c
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cnsmodels "github.com/emerishq/demeris-backend-models/cns"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRegistry(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		expErr bool
+	}{
+		{
+			"Registry with test chain",
+			TestRegistry,
+			false,
+		},
+		{
+			"Registry with JSON chains",
+			`{"chains":[{"chain_name":"akash","valid_block_thresh":"6s"}]}`,
+			false,
+		},
+		{
+			"Registry with invalid YAML",
+			"chains: [",
+			true,
+		},
+		{
+			"Registry with invalid threshold",
+			"chains:\n  - chain_name: akash\n    valid_block_thresh: often\n",
+			true,
+		},
+		{
+			"Registry with unnamed chain",
+			"chains:\n  - display_name: Akash\n",
+			true,
+		},
+		{
+			"Registry with duplicate chain",
+			"chains:\n  - chain_name: akash\n  - chain_name: akash\n",
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRegistry([]byte(tt.data))
+			if tt.expErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNewFileRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chains.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(TestRegistry), 0o600))
+
+	r, err := NewFileRegistry(path)
+	require.NoError(t, err)
+
+	chain, err := r.Chain(TestChainName)
+	require.NoError(t, err)
+	require.Equal(t, "Cosmos Hub", chain.DisplayName)
+	require.Equal(t, 10*time.Second, chain.ValidBlockThresh.Duration())
+	require.Equal(t, cnsmodels.DbStringMap{"cosmos-hub": "channel-0", "akash": "channel-1"}, chain.PrimaryChannel)
+	require.Len(t, chain.Denoms, 2)
+	require.Equal(t, "chainid", chain.NodeInfo.ChainID)
+
+	_, err = NewFileRegistry(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+}
+
+func TestFileRegistry(t *testing.T) {
+	r, err := NewRegistry([]byte(TestRegistry))
+	require.NoError(t, err)
+
+	chains, err := r.Chains()
+	require.NoError(t, err)
+	require.Len(t, chains, 1)
+	require.Equal(t, TestChainName, chains[0].ChainName)
+
+	_, err = r.Chain("invalid")
+	require.Error(t, err)
+
+	// modifying a returned chain doesn't modify the registry
+	chains[0].Denoms[0].DisplayName = "modified"
+	chain, err := r.Chain(TestChainName)
+	require.NoError(t, err)
+	require.Equal(t, "USD", chain.Denoms[0].DisplayName)
+
+	counterparty, err := r.GetCounterParty(TestChainName, "channel-1")
+	require.NoError(t, err)
+	require.Equal(t, []cnsmodels.ChannelQuery{
+		{
+			ChainName:    TestChainName,
+			Counterparty: "akash",
+			ChannelName:  "channel-1",
+		},
+	}, counterparty)
+
+	_, err = r.GetCounterParty(TestChainName, "test-channel")
+	require.Error(t, err)
+
+	_, err = r.GetCounterParty("invalid", "channel-1")
+	require.Error(t, err)
+
+	require.NoError(t, r.UpsertDenom(TestChainName, cnsmodels.Denom{Name: "uupsert", DisplayName: "Upsert"}))
+	require.NoError(t, r.UpsertDenom(TestChainName, cnsmodels.Denom{Name: "uupsert", DisplayName: "Upserted"}))
+	require.NoError(t, r.AddDenoms(TestChainName, cnsmodels.Denom{Name: "uatom", DisplayName: "changed"}, cnsmodels.Denom{Name: "uadded"}))
+	require.Error(t, r.UpsertDenom("invalid", cnsmodels.Denom{Name: "uupsert"}))
+	require.Error(t, r.AddDenoms("invalid", cnsmodels.Denom{Name: "uadded"}))
+
+	chain, err = r.Chain(TestChainName)
+	require.NoError(t, err)
+
+	names := map[string]string{}
+	for _, d := range chain.Denoms {
+		names[d.Name] = d.DisplayName
+	}
+	require.Equal(t, map[string]string{
+		"uusd":    "USD",
+		"uatom":   "ATOM",
+		"uupsert": "Upserted",
+		"uadded":  "",
+	}, names)
+
+	chain.Denoms = chain.Denoms[:1]
+	require.NoError(t, r.UpdateDenoms(chain))
+	require.Error(t, r.UpdateDenoms(cnsmodels.Chain{ChainName: "invalid"}))
+
+	chain, err = r.Chain(TestChainName)
+	require.NoError(t, err)
+	require.Len(t, chain.Denoms, 1)
+}
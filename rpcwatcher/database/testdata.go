@@ -44,3 +44,32 @@ var (
 	`,
 	}
 )
+
+// TestRegistry holds the test chain as a chain registry file.
+var TestRegistry = `
+chains:
+  - enabled: true
+    chain_name: ` + TestChainName + `
+    valid_block_thresh: 10s
+    logo: logo url
+    display_name: Cosmos Hub
+    primary_channel:
+      cosmos-hub: channel-0
+      akash: channel-1
+    denoms:
+      - {display_name: USD, name: uusd, verified: true, fee_token: true, fetch_price: true, fee_levels: {low: 1, average: 22, high: 42}, precision: 6}
+      - {display_name: ATOM, name: uatom, verified: true, fetch_price: true, fee_token: true, fee_levels: {low: 1, average: 22, high: 42}, precision: 6}
+    demeris_addresses: [feeaddress]
+    genesis_hash: genesis_hash
+    node_info:
+      endpoint: endpoint
+      chain_id: chainid
+      bech32_config:
+        main_prefix: main_prefix
+        prefix_account: prefix_account
+        prefix_validator: prefix_validator
+        prefix_consensus: prefix_consensus
+        prefix_public: prefix_public
+        prefix_operator: prefix_operator
+    derivation_path: m/44'/118'/0'/0/0
+`
@@ -1,16 +1,35 @@
 package rpcwatcher
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
-	"net/url"
+	"strings"
+
+	"google.golang.org/grpc"
 
 	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
 	cnsmodels "github.com/emerishq/demeris-backend-models/cns"
 	coretypes "github.com/tendermint/tendermint/rpc/core/types"
 )
 
+const (
+	lpDenomKeyFmt = "lp_denom/%s/%s"
+
+	// liquidity pool coins are minted with 6 decimals, unless their metadata says otherwise
+	defaultLPDenomPrecision = 6
+)
+
+// LPDenom describes the reserves of an LP denom: CNS denoms carry neither reserve denoms nor traces, so
+// they're recorded in the store along with the denom registration.
+type LPDenom struct {
+	Denom         string          `json:"denom"`
+	PoolID        string          `json:"pool_id"`
+	ReserveDenoms []string        `json:"reserve_denoms"`
+	Traces        []VerifiedTrace `json:"traces,omitempty"`
+}
+
 func isIBCToken(denom string) bool {
 	if len(denom) < 4 {
 		return false
@@ -18,99 +37,67 @@ func isIBCToken(denom string) bool {
 	return denom[:4] == "ibc/"
 }
 
-func formatDenom(w *Watcher, data coretypes.ResultEvent) (cnsmodels.Denom, error) {
+// formatDenom returns the LP denom created by data, along with the description of its reserves.
+func formatDenom(w *Watcher, data coretypes.ResultEvent) (cnsmodels.Denom, LPDenom, error) {
 	d := cnsmodels.Denom{}
+	lp := LPDenom{}
 
 	poolCoinDenom, ok := data.Events["create_pool.pool_coin_denom"]
 
 	if !ok {
-		return d, fmt.Errorf("failed to read pool coin denom")
+		return d, lp, fmt.Errorf("failed to read pool coin denom")
 	}
 
 	d.Name = poolCoinDenom[0]
 
 	depositCoins, ok := data.Events["create_pool.deposit_coins"]
 	if !ok {
-		return d, fmt.Errorf("failed to read deposit coins")
+		return d, lp, fmt.Errorf("failed to read deposit coins")
 	}
 
 	poolId, ok := data.Events["create_pool.pool_id"]
 	if !ok {
-		return d, fmt.Errorf("pool id not found in events")
+		return d, lp, fmt.Errorf("pool id not found in events")
 	}
 
 	coins, err := sdktypes.ParseCoinsNormalized(depositCoins[0])
 	if err != nil {
-		return d, fmt.Errorf("unable to parse deposit coins")
+		return d, lp, fmt.Errorf("unable to parse deposit coins")
 	}
 
 	cosmoshub, err := w.d.Chain("cosmos-hub")
 	if err != nil {
-		return d, err
+		return d, lp, err
 	}
 
+	lp.Denom = d.Name
+	lp.PoolID = poolId[0]
+
+	var tickers []string
 	for _, coin := range coins {
+		var reserve cnsmodels.Denom
 
 		if isIBCToken(coin.Denom) {
 
-			verifiedTrace := VerifyTraceResponse{}
-			w.l.Debugw("querying verified trace for coin", "coin", coin.Denom)
-
-			u, err := url.Parse(w.apiUrl)
-			if err != nil {
-				return d, err
-			}
-
-			u.Path = fmt.Sprintf("chain/%s/denom/verify_trace/%s", "cosmos-hub", coin.Denom[4:])
-			endpoint := u.String()
+			w.l.Debugw("verifying trace for coin", "coin", coin.Denom)
 
-			resp, err := http.Get(endpoint) //nolint url variable defined locally
+			verifiedTrace, err := w.verifyTrace(coin.Denom[4:])
 			if err != nil {
-				return d, err
+				return d, lp, err
 			}
 
-			if resp.StatusCode != 200 {
-				// retry if query fails
-				resp, err = http.Get(endpoint) //nolint url variable defined locally
+			w.l.Debugw("got trace", "trace", verifiedTrace)
 
-				if err != nil {
-					return d, err
-				}
+			// every hop was checked against the primary channel of the chain it starts from
+			if !verifiedTrace.Verified {
+				return d, lp, fmt.Errorf("not a verified denom")
 			}
 
-			dc := json.NewDecoder(resp.Body)
-
-			err = dc.Decode(&verifiedTrace)
+			lp.Traces = append(lp.Traces, verifiedTrace)
 
+			reserve, err = sourceDenom(w, verifiedTrace)
 			if err != nil {
-				return d, err
-			}
-
-			b, err := json.Marshal(verifiedTrace)
-
-			if err != nil {
-				return d, err
-			}
-
-			w.l.Debugw("got trace", "trace", string(b))
-
-			if !verifiedTrace.VerifyTrace.Verified {
-				return d, fmt.Errorf("not a verified denom")
-			}
-			if l := len(verifiedTrace.VerifyTrace.Trace); l != 1 {
-				return d, fmt.Errorf("trace too long, expected 1, got %d", l)
-			}
-
-			sourceChainName := verifiedTrace.VerifyTrace.Trace[0].CounterpartyName
-
-			primaryChannel, exists := cosmoshub.PrimaryChannel[sourceChainName]
-
-			if !exists {
-				return d, fmt.Errorf("no primary channel exists from %s to %s", verifiedTrace.VerifyTrace.Trace[0].ChainName, verifiedTrace.VerifyTrace.Trace[0].CounterpartyName)
-			}
-
-			if primaryChannel != verifiedTrace.VerifyTrace.Trace[0].Channel {
-				return d, fmt.Errorf("expected primary channel %s for verified ibc token, got %s", primaryChannel, verifiedTrace.VerifyTrace.Trace[0].Channel)
+				return d, lp, err
 			}
 
 		} else {
@@ -121,31 +108,139 @@ func formatDenom(w *Watcher, data coretypes.ResultEvent) (cnsmodels.Denom, error
 				if dd.Name == coin.Denom {
 
 					if !dd.Verified {
-						return d, fmt.Errorf("denom not verified in source chain")
+						return d, lp, fmt.Errorf("denom not verified in source chain")
 					}
 					found = true
+					reserve = dd
 					break
 
 				}
 			}
 
 			if !found {
-				return d, fmt.Errorf("denom not found in source chain")
+				return d, lp, fmt.Errorf("denom not found in source chain")
 			}
 
 		}
 
 		w.l.Debugw("verified denom", "denom", coin.Denom)
 
+		lp.ReserveDenoms = append(lp.ReserveDenoms, coin.Denom)
+		tickers = append(tickers, denomTicker(reserve))
 	}
 
-	d.DisplayName = fmt.Sprintf("Gravity %s", poolId[0])
-	d.Ticker = fmt.Sprintf("G%s", poolId[0])
+	d.DisplayName = fmt.Sprintf("%s LP", strings.Join(tickers, "/"))
+	d.Ticker = strings.Join(tickers, "/")
 
 	d.Verified = true
-	d.Precision = 6
+	d.Precision = defaultLPDenomPrecision
 
-	w.l.Debugw("verified lp denom", "displayname", d.DisplayName, "ticker", d.Ticker)
+	metadata, err := w.queryDenomMetadata(d.Name)
+	if err != nil {
+		w.l.Debugw("no metadata for lp denom", "denom", d.Name, "error", err)
+	} else if precision, ok := displayExponent(metadata); ok {
+		d.Precision = precision
+	}
+
+	w.l.Debugw("verified lp denom", "displayname", d.DisplayName, "ticker", d.Ticker, "precision", d.Precision)
+
+	return d, lp, nil
+}
+
+// sourceDenom returns the CNS denom a verified trace leads to, on the chain it originates from.
+func sourceDenom(w *Watcher, vt VerifiedTrace) (cnsmodels.Denom, error) {
+	chainName := w.Name
+	if l := len(vt.Trace); l > 0 {
+		chainName = vt.Trace[l-1].CounterpartyName
+	}
+
+	source, err := w.d.Chain(chainName)
+	if err != nil {
+		return cnsmodels.Denom{}, err
+	}
+
+	for _, dd := range source.Denoms {
+		if dd.Name == vt.BaseDenom {
+			return dd, nil
+		}
+	}
+
+	return cnsmodels.Denom{}, fmt.Errorf("denom %s not found on chain %s", vt.BaseDenom, chainName)
+}
+
+// denomTicker returns the ticker of d, falling back to its display name then its name.
+func denomTicker(d cnsmodels.Denom) string {
+	switch {
+	case d.Ticker != "":
+		return d.Ticker
+	case d.DisplayName != "":
+		return d.DisplayName
+	default:
+		return strings.ToUpper(d.Name)
+	}
+}
+
+// queryDenomMetadata queries the x/bank metadata of denom on the watched chain.
+func (w *Watcher) queryDenomMetadata(denom string) (banktypes.Metadata, error) {
+	grpcConn, err := grpc.Dial(
+		w.grpcEndpoint,
+		grpc.WithInsecure(),
+	)
+	if err != nil {
+		return banktypes.Metadata{}, fmt.Errorf("cannot create gRPC client, %w", err)
+	}
+
+	defer func() {
+		if err := grpcConn.Close(); err != nil {
+			w.l.Errorw("cannot close gRPC client", "error", err, "chain_name", w.Name)
+		}
+	}()
+
+	res, err := banktypes.NewQueryClient(grpcConn).DenomMetadata(context.Background(), &banktypes.QueryDenomMetadataRequest{
+		Denom: denom,
+	})
+	if err != nil {
+		return banktypes.Metadata{}, fmt.Errorf("cannot query metadata of %s, %w", denom, err)
+	}
+
+	return res.Metadata, nil
+}
+
+// displayExponent returns the exponent of the display unit of metadata, if it lists one.
+func displayExponent(metadata banktypes.Metadata) (int64, bool) {
+	for _, u := range metadata.DenomUnits {
+		if u != nil && u.Denom == metadata.Display {
+			return int64(u.Exponent), true
+		}
+	}
+
+	return 0, false
+}
+
+// recordLPDenom stores the reserves of the LP denom created on chainName.
+func recordLPDenom(w *Watcher, chainName string, lp LPDenom) {
+	bz, err := json.Marshal(lp)
+	if err != nil {
+		w.l.Errorw("cannot marshal lp denom", "denom", lp.Denom, "error", err)
+		return
+	}
+
+	if err := w.store.SetWithExpiry(fmt.Sprintf(lpDenomKeyFmt, chainName, lp.Denom), string(bz), 0); err != nil {
+		w.l.Errorw("cannot set lp denom", "denom", lp.Denom, "error", err)
+	}
+}
+
+// LPDenomReserves returns the reserves of the LP denom created on chainName.
+func LPDenomReserves(s TicketStore, chainName, denom string) (LPDenom, error) {
+	res, err := s.Value(fmt.Sprintf(lpDenomKeyFmt, chainName, denom))
+	if err != nil {
+		return LPDenom{}, err
+	}
+
+	var lp LPDenom
+	if err := json.Unmarshal(res, &lp); err != nil {
+		return LPDenom{}, fmt.Errorf("cannot unmarshal lp denom, %w", err)
+	}
 
-	return d, nil
+	return lp, nil
 }
@@ -3,6 +3,8 @@ package rpcwatcher
 import (
 	"testing"
 
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	cnsmodels "github.com/emerishq/demeris-backend-models/cns"
 	"github.com/stretchr/testify/require"
 )
 
@@ -36,3 +38,76 @@ func TestIsIBCToken(t *testing.T) {
 		})
 	}
 }
+
+func TestDenomTicker(t *testing.T) {
+	tests := []struct {
+		name      string
+		denom     cnsmodels.Denom
+		expTicker string
+	}{
+		{
+			"ticker set",
+			cnsmodels.Denom{Name: "uatom", DisplayName: "Cosmos Hub Atom", Ticker: "ATOM"},
+			"ATOM",
+		},
+		{
+			"display name only",
+			cnsmodels.Denom{Name: "uusd", DisplayName: "USD"},
+			"USD",
+		},
+		{
+			"name only",
+			cnsmodels.Denom{Name: "uosmo"},
+			"UOSMO",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expTicker, denomTicker(tt.denom))
+		})
+	}
+}
+
+func TestDisplayExponent(t *testing.T) {
+	tests := []struct {
+		name        string
+		metadata    banktypes.Metadata
+		expExponent int64
+		expFound    bool
+	}{
+		{
+			"display unit exponent",
+			banktypes.Metadata{
+				Base:    "pool1",
+				Display: "pool",
+				DenomUnits: []*banktypes.DenomUnit{
+					{Denom: "pool1", Exponent: 0},
+					{Denom: "pool", Exponent: 12},
+				},
+			},
+			12,
+			true,
+		},
+		{
+			"display unit missing",
+			banktypes.Metadata{
+				Base:    "pool1",
+				Display: "pool",
+				DenomUnits: []*banktypes.DenomUnit{
+					{Denom: "pool1", Exponent: 0},
+				},
+			},
+			0,
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exponent, found := displayExponent(tt.metadata)
+			require.Equal(t, tt.expFound, found)
+			require.Equal(t, tt.expExponent, exponent)
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package rpcwatcher
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+
+	"github.com/cosmos/cosmos-sdk/types/query"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	cnsmodels "github.com/emerishq/demeris-backend-models/cns"
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+// PrecisionChange flags a CNS denom whose precision differs from the exponent of its x/bank display unit.
+type PrecisionChange struct {
+	Denom             string `json:"denom"`
+	Precision         int64  `json:"precision"`
+	MetadataPrecision int64  `json:"metadata_precision"`
+}
+
+// DenomsDiff holds the differences between the denoms a chain registers and the CNS ones.
+type DenomsDiff struct {
+	New              []cnsmodels.Denom `json:"new,omitempty"`
+	PrecisionChanges []PrecisionChange `json:"precision_changes,omitempty"`
+}
+
+// Empty returns true when the chain and CNS denoms agree.
+func (d DenomsDiff) Empty() bool {
+	return len(d.New) == 0 && len(d.PrecisionChanges) == 0
+}
+
+// SetDenomSync makes the watcher compare the denoms of the watched chain with the CNS ones every interval
+// blocks, a zero interval disables it.
+// New denoms are added to CNS as unverified unless dryRun is set, in which case the diff is only logged.
+func (w *Watcher) SetDenomSync(interval int64, dryRun bool) {
+	w.denomSyncInterval = interval
+	w.denomSyncDryRun = dryRun
+}
+
+// HandleDenomSync syncs the CNS denoms of the watched chain with its x/bank metadata and supply, without
+// blocking the handling of the following events.
+func HandleDenomSync(w *Watcher, data coretypes.ResultEvent) {
+	realData, ok := data.Data.(types.EventDataNewBlock)
+	if !ok {
+		panic("rpc returned block data which is not of expected type")
+	}
+
+	if realData.Block == nil || w.denomSyncInterval <= 0 || realData.Block.Height%w.denomSyncInterval != 0 {
+		return
+	}
+
+	// a sync still running when the next one is due makes the latter a no-op
+	if !atomic.CompareAndSwapInt32(&w.denomSyncing, 0, 1) {
+		return
+	}
+
+	go func() {
+		defer atomic.StoreInt32(&w.denomSyncing, 0)
+
+		grpcConn, err := grpc.Dial(
+			w.grpcEndpoint,
+			grpc.WithInsecure(),
+		)
+		if err != nil {
+			w.l.Errorw("cannot create gRPC client", "error", err, "chain_name", w.Name, "address", w.grpcEndpoint)
+			return
+		}
+
+		defer func() {
+			if err := grpcConn.Close(); err != nil {
+				w.l.Errorw("cannot close gRPC client", "error", err, "chain_name", w.Name)
+			}
+		}()
+
+		if err := syncDenoms(w, grpcConn); err != nil {
+			w.l.Errorw("cannot sync denoms", "chain_name", w.Name, "error", err)
+		}
+	}()
+}
+
+// syncDenoms compares the denoms registered on the watched chain, queried over conn, with its CNS denoms.
+func syncDenoms(w *Watcher, conn *grpc.ClientConn) error {
+	bankQuery := banktypes.NewQueryClient(conn)
+
+	supply, err := bankQuery.TotalSupply(context.Background(), &banktypes.QueryTotalSupplyRequest{})
+	if err != nil {
+		return fmt.Errorf("cannot query total supply, %w", err)
+	}
+
+	var metadata []banktypes.Metadata
+	var nextKey []byte
+	for {
+		res, err := bankQuery.DenomsMetadata(context.Background(), &banktypes.QueryDenomsMetadataRequest{
+			Pagination: &query.PageRequest{Key: nextKey},
+		})
+		if err != nil {
+			return fmt.Errorf("cannot query denoms metadata, %w", err)
+		}
+
+		metadata = append(metadata, res.Metadatas...)
+
+		if res.Pagination == nil || len(res.Pagination.NextKey) == 0 {
+			break
+		}
+
+		nextKey = res.Pagination.NextKey
+	}
+
+	chain, err := w.d.Chain(w.Name)
+	if err != nil {
+		return err
+	}
+
+	denoms := make([]string, 0, len(supply.Supply))
+	for _, c := range supply.Supply {
+		denoms = append(denoms, c.Denom)
+	}
+
+	diff := diffDenoms(chain.Denoms, denoms, metadata)
+	if diff.Empty() {
+		return nil
+	}
+
+	for _, pc := range diff.PrecisionChanges {
+		w.l.Warnw("cns denom precision differs from chain metadata", "chain_name", w.Name, "denom", pc.Denom,
+			"precision", pc.Precision, "metadata_precision", pc.MetadataPrecision)
+	}
+
+	for _, d := range diff.New {
+		w.l.Infow("denom missing from cns", "chain_name", w.Name, "denom", d.Name, "precision", d.Precision,
+			"dry_run", w.denomSyncDryRun)
+	}
+
+	if w.denomSyncDryRun || len(diff.New) == 0 {
+		return nil
+	}
+
+	return w.d.AddDenoms(w.Name, diff.New...)
+}
+
+// diffDenoms returns the denoms of supply and metadata missing from cnsDenoms, as unverified denoms, and the
+// CNS denoms whose precision doesn't match their metadata.
+// IBC vouchers are left out, since they're registered along with their trace when received.
+func diffDenoms(cnsDenoms cnsmodels.DenomList, supply []string, metadata []banktypes.Metadata) DenomsDiff {
+	known := map[string]cnsmodels.Denom{}
+	for _, d := range cnsDenoms {
+		known[d.Name] = d
+	}
+
+	missing := map[string]cnsmodels.Denom{}
+	for _, denom := range supply {
+		if _, ok := known[denom]; ok || isIBCToken(denom) {
+			continue
+		}
+
+		missing[denom] = cnsmodels.Denom{Name: denom}
+	}
+
+	var diff DenomsDiff
+	for _, m := range metadata {
+		if isIBCToken(m.Base) {
+			continue
+		}
+
+		precision, hasPrecision := displayExponent(m)
+
+		if d, ok := known[m.Base]; ok {
+			if hasPrecision && d.Precision != precision {
+				diff.PrecisionChanges = append(diff.PrecisionChanges, PrecisionChange{
+					Denom:             d.Name,
+					Precision:         d.Precision,
+					MetadataPrecision: precision,
+				})
+			}
+
+			continue
+		}
+
+		d := cnsmodels.Denom{Name: m.Base}
+		if hasPrecision {
+			d.Precision = precision
+		}
+
+		if m.Display != "" {
+			d.DisplayName = strings.ToUpper(m.Display)
+			d.Ticker = strings.ToUpper(m.Display)
+		}
+
+		missing[m.Base] = d
+	}
+
+	for _, d := range missing {
+		diff.New = append(diff.New, d)
+	}
+
+	sort.Slice(diff.New, func(i, j int) bool {
+		return diff.New[i].Name < diff.New[j].Name
+	})
+
+	return diff
+}
@@ -0,0 +1,139 @@
+package rpcwatcher
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	cnsmodels "github.com/emerishq/demeris-backend-models/cns"
+	"github.com/emerishq/emeris-rpcwatcher/rpcwatcher/database"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type testDenomsQueryServer struct {
+	*banktypes.UnimplementedQueryServer
+	supply   sdktypes.Coins
+	metadata []banktypes.Metadata
+}
+
+func (q testDenomsQueryServer) TotalSupply(context.Context, *banktypes.QueryTotalSupplyRequest) (*banktypes.QueryTotalSupplyResponse, error) {
+	return &banktypes.QueryTotalSupplyResponse{Supply: q.supply}, nil
+}
+
+func (q testDenomsQueryServer) DenomsMetadata(context.Context, *banktypes.QueryDenomsMetadataRequest) (*banktypes.QueryDenomsMetadataResponse, error) {
+	return &banktypes.QueryDenomsMetadataResponse{Metadatas: q.metadata}, nil
+}
+
+func testMetadata(base, display string, exponent uint32) banktypes.Metadata {
+	return banktypes.Metadata{
+		Base:    base,
+		Display: display,
+		DenomUnits: []*banktypes.DenomUnit{
+			{Denom: base, Exponent: 0},
+			{Denom: display, Exponent: exponent},
+		},
+	}
+}
+
+func TestDiffDenoms(t *testing.T) {
+	cnsDenoms := cnsmodels.DenomList{
+		{Name: "uatom", DisplayName: "ATOM", Precision: 6, Verified: true},
+		{Name: "uusd", DisplayName: "USD", Precision: 6, Verified: true},
+	}
+
+	tests := []struct {
+		name     string
+		supply   []string
+		metadata []banktypes.Metadata
+		expDiff  DenomsDiff
+	}{
+		{
+			"chain and cns agree",
+			[]string{"uatom", "uusd"},
+			[]banktypes.Metadata{testMetadata("uatom", "atom", 6)},
+			DenomsDiff{},
+		},
+		{
+			"new denoms from supply and metadata",
+			[]string{"uatom", "uusd", "stake", "ibc/B5CB286F69D48B2C4F6F8D8CF59011C40590DCF8A91617A5FBA9FF0A7B21307F"},
+			[]banktypes.Metadata{testMetadata("ujuno", "juno", 6)},
+			DenomsDiff{
+				New: []cnsmodels.Denom{
+					{Name: "stake"},
+					{Name: "ujuno", DisplayName: "JUNO", Ticker: "JUNO", Precision: 6},
+				},
+			},
+		},
+		{
+			"changed exponent",
+			[]string{"uatom", "uusd"},
+			[]banktypes.Metadata{testMetadata("uusd", "usd", 18)},
+			DenomsDiff{
+				PrecisionChanges: []PrecisionChange{
+					{Denom: "uusd", Precision: 6, MetadataPrecision: 18},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := diffDenoms(cnsDenoms, tt.supply, tt.metadata)
+			require.Equal(t, tt.expDiff, diff)
+			require.Equal(t, len(tt.expDiff.New) == 0 && len(tt.expDiff.PrecisionChanges) == 0, diff.Empty())
+		})
+	}
+}
+
+func TestSyncDenoms(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	banktypes.RegisterQueryServer(srv, testDenomsQueryServer{
+		supply:   sdktypes.NewCoins(sdktypes.NewInt64Coin("uatom", 1000), sdktypes.NewInt64Coin("usync", 1000)),
+		metadata: []banktypes.Metadata{testMetadata("usync", "sync", 6)},
+	})
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(
+		"bufnet",
+		grpc.WithInsecure(),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return lis.Dial()
+		}),
+	)
+	require.NoError(t, err)
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	watcherInstance := &Watcher{
+		l:     logger,
+		d:     dbInstance,
+		store: NewRedisStore(s),
+		Name:  database.TestChainName,
+	}
+
+	watcherInstance.SetDenomSync(1, true)
+	require.NoError(t, syncDenoms(watcherInstance, conn))
+	checkDenomExists(t, watcherInstance, "usync", false)
+
+	watcherInstance.SetDenomSync(1, false)
+	require.NoError(t, syncDenoms(watcherInstance, conn))
+	checkDenomExists(t, watcherInstance, "usync", true)
+
+	c, err := dbInstance.Chain(database.TestChainName)
+	require.NoError(t, err)
+	for _, d := range c.Denoms {
+		if d.Name == "usync" {
+			require.False(t, d.Verified)
+			require.Equal(t, int64(6), d.Precision)
+		}
+	}
+}
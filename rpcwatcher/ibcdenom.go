@@ -0,0 +1,167 @@
+package rpcwatcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	transfertypes "github.com/cosmos/cosmos-sdk/x/ibc/applications/transfer/types"
+	cnsmodels "github.com/emerishq/demeris-backend-models/cns"
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+const ibcDenomTraceKeyFmt = "ibc_denom/%s/%s"
+
+// IBCDenomTrace describes an IBC voucher denom discovered on a chain: CNS denoms carry no trace, so it's
+// recorded in the store along with the denom registration.
+type IBCDenomTrace struct {
+	Denom       string `json:"denom"`
+	BaseDenom   string `json:"base_denom"`
+	Path        string `json:"path"`
+	SourceChain string `json:"source_chain,omitempty"`
+}
+
+func ibcDenomTraceKey(chainName, denom string) string {
+	return fmt.Sprintf(ibcDenomTraceKeyFmt, chainName, strings.TrimPrefix(denom, "ibc/"))
+}
+
+// receivedDenomTraces returns the traces of the vouchers minted by the fungible token packets received
+// in data.
+// A transaction may carry many packets, so the traces are rebuilt from every destination channel and
+// packet denom pair, and only those whose hash matches a minted voucher are kept.
+func receivedDenomTraces(data coretypes.ResultEvent) []transfertypes.DenomTrace {
+	minted := map[string]bool{}
+	for _, d := range data.Events[transfertypes.EventTypeDenomTrace+"."+transfertypes.AttributeKeyDenom] {
+		minted[d] = true
+	}
+
+	if len(minted) == 0 {
+		return nil
+	}
+
+	ports := data.Events["recv_packet.packet_dst_port"]
+	channels := data.Events["recv_packet.packet_dst_channel"]
+	if len(ports) != len(channels) {
+		return nil
+	}
+
+	var traces []transfertypes.DenomTrace
+	for i, port := range ports {
+		if !isICS20Port(port) {
+			continue
+		}
+
+		for _, denom := range data.Events[transfertypes.EventTypePacket+"."+transfertypes.AttributeKeyDenom] {
+			trace := transfertypes.ParseDenomTrace(transfertypes.GetPrefixedDenom(port, channels[i], denom))
+
+			ibcDenom := trace.IBCDenom()
+			if !minted[ibcDenom] {
+				continue
+			}
+
+			delete(minted, ibcDenom)
+			traces = append(traces, trace)
+		}
+	}
+
+	return traces
+}
+
+// traceSourceChain walks the hops of trace starting from chainName, and returns the chain the IBC denom
+// originates from.
+// Only the first hop goes through a channel of the watched chain, the following ones are looked up in CNS.
+func (w *Watcher) traceSourceChain(chainName string, trace transfertypes.DenomTrace) (string, error) {
+	hops, err := traceHops(trace.Path)
+	if err != nil {
+		return "", err
+	}
+
+	source, err := w.counterparty(chainName, hops[0][0], hops[0][1])
+	if err != nil {
+		return "", err
+	}
+
+	for _, hop := range hops[1:] {
+		c, err := w.d.GetCounterParty(source, hop[1])
+		if err != nil {
+			return "", err
+		}
+
+		source = c[0].Counterparty
+	}
+
+	return source, nil
+}
+
+// discoverIBCDenoms registers in CNS, as unverified, the IBC vouchers received in data which chainName
+// doesn't list yet.
+func discoverIBCDenoms(w *Watcher, data coretypes.ResultEvent, chainName string) {
+	var discovered []IBCDenomTrace
+	for _, trace := range receivedDenomTraces(data) {
+		if w.store.Exists(ibcDenomTraceKey(chainName, trace.IBCDenom())) {
+			continue
+		}
+
+		t := IBCDenomTrace{
+			Denom:     trace.IBCDenom(),
+			BaseDenom: trace.BaseDenom,
+			Path:      trace.Path,
+		}
+
+		sourceChain, err := w.traceSourceChain(chainName, trace)
+		if err != nil {
+			w.l.Errorw("cannot resolve source chain of ibc denom", "chain_name", chainName, "denom", t.Denom, "path", t.Path, "error", err)
+		}
+
+		t.SourceChain = sourceChain
+
+		discovered = append(discovered, t)
+	}
+
+	if len(discovered) == 0 {
+		return
+	}
+
+	denoms := make([]cnsmodels.Denom, 0, len(discovered))
+	for _, t := range discovered {
+		denoms = append(denoms, cnsmodels.Denom{
+			Name:        t.Denom,
+			DisplayName: t.BaseDenom,
+		})
+
+		w.l.Debugw("discovered ibc denom", "chain_name", chainName, "denom", t.Denom, "base_denom", t.BaseDenom,
+			"path", t.Path, "source_chain", t.SourceChain)
+	}
+
+	if err := w.d.AddDenoms(chainName, denoms...); err != nil {
+		w.l.Errorw("failed to update chain", "chain_name", chainName, "error", err)
+		return
+	}
+
+	for _, t := range discovered {
+		bz, err := json.Marshal(t)
+		if err != nil {
+			w.l.Errorw("cannot marshal ibc denom trace", "denom", t.Denom, "error", err)
+			continue
+		}
+
+		if err := w.store.SetWithExpiry(ibcDenomTraceKey(chainName, t.Denom), string(bz), 0); err != nil {
+			w.l.Errorw("cannot set ibc denom trace", "denom", t.Denom, "error", err)
+		}
+	}
+}
+
+// IBCDenom returns the trace recorded for the IBC voucher denom discovered on chainName.
+func IBCDenom(s TicketStore, chainName, denom string) (IBCDenomTrace, error) {
+	res, err := s.Value(ibcDenomTraceKey(chainName, denom))
+	if err != nil {
+		return IBCDenomTrace{}, err
+	}
+
+	var t IBCDenomTrace
+	if err := json.Unmarshal(res, &t); err != nil {
+		return IBCDenomTrace{}, fmt.Errorf("cannot unmarshal ibc denom trace, %w", err)
+	}
+
+	return t, nil
+}
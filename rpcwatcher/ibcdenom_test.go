@@ -0,0 +1,163 @@
+package rpcwatcher
+
+import (
+	"testing"
+
+	transfertypes "github.com/cosmos/cosmos-sdk/x/ibc/applications/transfer/types"
+	"github.com/emerishq/emeris-rpcwatcher/rpcwatcher/database"
+	"github.com/emerishq/emeris-utils/store"
+	"github.com/stretchr/testify/require"
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+func recvPacketEvent(ports, channels, packetDenoms, minted []string) coretypes.ResultEvent {
+	return coretypes.ResultEvent{
+		Events: map[string][]string{
+			"recv_packet.packet_dst_port":    ports,
+			"recv_packet.packet_dst_channel": channels,
+			"fungible_token_packet.denom":    packetDenoms,
+			"denomination_trace.denom":       minted,
+		},
+	}
+}
+
+func TestReceivedDenomTraces(t *testing.T) {
+	akt := transfertypes.ParseDenomTrace("transfer/channel-1/uakt")
+	osmo := transfertypes.ParseDenomTrace("transfer/channel-2/uosmo")
+	multiHop := transfertypes.ParseDenomTrace("transfer/channel-1/transfer/channel-7/ujuno")
+
+	tests := []struct {
+		name      string
+		data      coretypes.ResultEvent
+		expTraces []transfertypes.DenomTrace
+	}{
+		{
+			"no voucher minted",
+			recvPacketEvent([]string{"transfer"}, []string{"channel-0"}, []string{"transfer/channel-9/uatom"}, nil),
+			nil,
+		},
+		{
+			"single packet",
+			recvPacketEvent([]string{"transfer"}, []string{"channel-1"}, []string{"uakt"}, []string{akt.IBCDenom()}),
+			[]transfertypes.DenomTrace{akt},
+		},
+		{
+			"multi hop packet",
+			recvPacketEvent([]string{"transfer"}, []string{"channel-1"}, []string{"transfer/channel-7/ujuno"}, []string{multiHop.IBCDenom()}),
+			[]transfertypes.DenomTrace{multiHop},
+		},
+		{
+			"many packets, one unwinding",
+			recvPacketEvent(
+				[]string{"transfer", "transfer", "transfer"},
+				[]string{"channel-1", "channel-0", "channel-2"},
+				[]string{"uakt", "transfer/channel-0/uatom", "uosmo"},
+				[]string{akt.IBCDenom(), osmo.IBCDenom()},
+			),
+			[]transfertypes.DenomTrace{akt, osmo},
+		},
+		{
+			"non fungible token port",
+			recvPacketEvent([]string{"nft-transfer"}, []string{"channel-1"}, []string{"uakt"}, []string{akt.IBCDenom()}),
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expTraces, receivedDenomTraces(tt.data))
+		})
+	}
+}
+
+func TestDiscoverIBCDenoms(t *testing.T) {
+	defer store.ResetTestStore(mr, s)
+
+	watcherInstance := &Watcher{
+		l:     logger,
+		d:     dbInstance,
+		store: NewRedisStore(s),
+		Name:  database.TestChainName,
+	}
+
+	akt := transfertypes.ParseDenomTrace("transfer/channel-1/uakt")
+	data := recvPacketEvent([]string{"transfer"}, []string{"channel-1"}, []string{"uakt"}, []string{akt.IBCDenom()})
+
+	checkDenomExists(t, watcherInstance, akt.IBCDenom(), false)
+
+	discoverIBCDenoms(watcherInstance, data, database.TestChainName)
+	checkDenomExists(t, watcherInstance, akt.IBCDenom(), true)
+
+	trace, err := IBCDenom(NewRedisStore(s), database.TestChainName, akt.IBCDenom())
+	require.NoError(t, err)
+	require.Equal(t, IBCDenomTrace{
+		Denom:       akt.IBCDenom(),
+		BaseDenom:   "uakt",
+		Path:        "transfer/channel-1",
+		SourceChain: "akash",
+	}, trace)
+
+	// receiving the same voucher again doesn't register it twice
+	discoverIBCDenoms(watcherInstance, data, database.TestChainName)
+
+	c, err := dbInstance.Chain(database.TestChainName)
+	require.NoError(t, err)
+
+	count := 0
+	for _, d := range c.Denoms {
+		if d.Name == akt.IBCDenom() {
+			require.False(t, d.Verified)
+			require.Equal(t, "uakt", d.DisplayName)
+			count++
+		}
+	}
+	require.Equal(t, 1, count)
+}
+
+func TestTraceSourceChain(t *testing.T) {
+	watcherInstance := &Watcher{
+		l:     logger,
+		d:     dbInstance,
+		store: NewRedisStore(s),
+		Name:  database.TestChainName,
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		expSourceChain string
+		expErr         bool
+	}{
+		{
+			"single hop",
+			"transfer/channel-1/uakt",
+			"akash",
+			false,
+		},
+		{
+			"multi hop",
+			"transfer/channel-0/transfer/channel-1/uakt",
+			"akash",
+			false,
+		},
+		{
+			"unknown hop",
+			"transfer/channel-0/transfer/channel-42/uakt",
+			"",
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sourceChain, err := watcherInstance.traceSourceChain(database.TestChainName, transfertypes.ParseDenomTrace(tt.path))
+			if tt.expErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expSourceChain, sourceChain)
+		})
+	}
+}
@@ -0,0 +1,563 @@
+package rpcwatcher
+
+import (
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/codec"
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	gaia "github.com/cosmos/gaia/v5/app"
+	"github.com/emerishq/emeris-utils/store"
+)
+
+const (
+	// defaultMemoryStoreExpiry matches the default expiry of store.Store
+	defaultMemoryStoreExpiry = 300 * time.Second
+
+	// ticket statuses and expiries set by store.Store transitions
+	transitStatus               = "transit"
+	completeStatus              = "complete"
+	failedStatus                = "failed"
+	ibcReceiveFailedStatus      = "IBC_receive_failed"
+	ibcReceiveSuccessStatus     = "IBC_receive_success"
+	tokensUnlockedTimeoutStatus = "Tokens_unlocked_timeout"
+	tokensUnlockedAckStatus     = "Tokens_unlocked_ack"
+	shadowKeyPrefix             = "shadow"
+	poolSwapFeesKeyFmt          = "pool/%s/%d"
+	poolSwapFeesExpiryMul       = 12
+
+	// blocks are stored like store.Blocks does
+	blockKeyFmt     = "block/%d"
+	blockTimeKeyFmt = "blockTime/%d"
+	blockExpiry     = 100 * 10 * time.Second
+)
+
+// memEntry is a value of the memory store, of whichever kind it was written as.
+type memEntry struct {
+	value     []byte
+	list      [][]byte
+	coins     sdktypes.Coins
+	set       map[string]bool
+	expiresAt time.Time
+}
+
+func (e *memEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// MemoryStore is a TicketStore held in memory, for tests, demos and deployments running every
+// service in a single binary.
+// Tickets go through the same transitions as with store.Store, so that they read the same.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memEntry
+	blocks  map[string]map[int64][]byte
+	expiry  time.Duration
+	cdc     codec.Marshaler
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	cdc, _ := gaia.MakeCodecs()
+
+	return &MemoryStore{
+		entries: map[string]*memEntry{},
+		blocks:  map[string]map[int64][]byte{},
+		expiry:  defaultMemoryStoreExpiry,
+		cdc:     cdc,
+	}
+}
+
+// entry returns the live entry stored at key, dropping it if expired.
+// Callers must hold s.mu.
+func (s *MemoryStore) entry(key string) (*memEntry, bool) {
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	if e.expired(time.Now()) {
+		delete(s.entries, key)
+		return nil, false
+	}
+
+	return e, true
+}
+
+func (s *MemoryStore) set(key string, value interface{}, expiry time.Duration) error {
+	bz, err := encodeValue(value)
+	if err != nil {
+		return err
+	}
+
+	e := &memEntry{value: bz}
+	if expiry > 0 {
+		e.expiresAt = time.Now().Add(expiry)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = e
+	return nil
+}
+
+// encodeValue encodes value the way go-redis does when writing it.
+func encodeValue(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return []byte{}, nil
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return append([]byte{}, v...), nil
+	case encoding.BinaryMarshaler:
+		return v.MarshalBinary()
+	default:
+		return []byte(fmt.Sprint(v)), nil
+	}
+}
+
+func (s *MemoryStore) Exists(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.entry(key)
+	return ok
+}
+
+func (s *MemoryStore) Value(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entry(key)
+	if !ok || e.value == nil {
+		return nil, ErrKeyNotFound
+	}
+
+	return append([]byte{}, e.value...), nil
+}
+
+func (s *MemoryStore) Get(key string) (store.Ticket, error) {
+	bz, err := s.Value(key)
+	if err != nil {
+		return store.Ticket{}, err
+	}
+
+	var t store.Ticket
+	if err := json.Unmarshal(bz, &t); err != nil {
+		return store.Ticket{}, err
+	}
+
+	return t, nil
+}
+
+func (s *MemoryStore) SetWithExpiry(key string, value interface{}, mul int64) error {
+	return s.set(key, value, time.Duration(mul)*s.expiry)
+}
+
+func (s *MemoryStore) SetWithExpiryTime(key string, value interface{}, duration time.Duration) error {
+	return s.set(key, value, duration)
+}
+
+func (s *MemoryStore) SetKeepTTL(key string, value interface{}) error {
+	bz, err := encodeValue(value)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := &memEntry{value: bz}
+	if prev, ok := s.entry(key); ok {
+		e.expiresAt = prev.expiresAt
+	}
+
+	s.entries[key] = e
+	return nil
+}
+
+func (s *MemoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// ScanKeys walks the keys starting with prefix in lexical order, cursor being the number of keys
+// already returned.
+func (s *MemoryStore) ScanKeys(prefix string, cursor uint64, count int64) ([]string, uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []string
+	for k := range s.entries {
+		if _, ok := s.entry(k); ok && strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	if cursor >= uint64(len(keys)) {
+		return []string{}, 0, nil
+	}
+
+	next := cursor + uint64(count)
+	if next >= uint64(len(keys)) {
+		return keys[cursor:], 0, nil
+	}
+
+	return keys[cursor:next], next, nil
+}
+
+func (s *MemoryStore) AddMembers(key string, members ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entry(key)
+	if !ok || e.set == nil {
+		e = &memEntry{set: map[string]bool{}}
+		s.entries[key] = e
+	}
+
+	for _, m := range members {
+		e.set[m] = true
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) Members(key string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entry(key)
+	if !ok {
+		return []string{}, nil
+	}
+
+	members := make([]string, 0, len(e.set))
+	for m := range e.set {
+		members = append(members, m)
+	}
+
+	sort.Strings(members)
+	return members, nil
+}
+
+func (s *MemoryStore) RemoveMembers(key string, members ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entry(key)
+	if !ok {
+		return nil
+	}
+
+	for _, m := range members {
+		delete(e.set, m)
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) Append(key string, value []byte, expiry time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entry(key)
+	if !ok {
+		e = &memEntry{}
+		s.entries[key] = e
+	}
+
+	e.list = append(e.list, append([]byte{}, value...))
+	e.expiresAt = time.Now().Add(expiry)
+	return nil
+}
+
+func (s *MemoryStore) List(key string) ([][]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entry(key)
+	if !ok {
+		return [][]byte{}, nil
+	}
+
+	values := make([][]byte, 0, len(e.list))
+	for _, v := range e.list {
+		values = append(values, append([]byte{}, v...))
+	}
+
+	return values, nil
+}
+
+func (s *MemoryStore) AddCoins(key string, coins sdktypes.Coins, expireAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entry(key)
+	if !ok {
+		e = &memEntry{coins: sdktypes.NewCoins()}
+		s.entries[key] = e
+	}
+
+	e.coins = e.coins.Add(coins...)
+	e.expiresAt = expireAt
+	return nil
+}
+
+func (s *MemoryStore) Coins(key string) (sdktypes.Coins, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entry(key)
+	if !ok {
+		return sdktypes.NewCoins(), nil
+	}
+
+	return sdktypes.NewCoins(e.coins...), nil
+}
+
+func (s *MemoryStore) AddBlock(data []byte, height int64) error {
+	return s.SetWithExpiryTime(fmt.Sprintf(blockKeyFmt, height), data, blockExpiry)
+}
+
+func (s *MemoryStore) SetLastBlockTime(t time.Time, height int64) error {
+	bz, err := t.MarshalText()
+	if err != nil {
+		return err
+	}
+
+	return s.SetWithExpiryTime(fmt.Sprintf(blockTimeKeyFmt, height), bz, blockExpiry)
+}
+
+func (s *MemoryStore) SetBlockResults(chainName string, height int64, bz []byte, retention int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results, ok := s.blocks[chainName]
+	if !ok {
+		results = map[int64][]byte{}
+		s.blocks[chainName] = results
+	}
+
+	results[height] = append([]byte{}, bz...)
+
+	heights := sortedHeights(results)
+	for i := 0; i < len(heights)-int(retention); i++ {
+		delete(results, heights[i])
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) BlockResults(chainName string, height int64) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bz, ok := s.blocks[chainName][height]
+	if !ok {
+		return nil, store.ErrBlockNotFound
+	}
+
+	return append([]byte{}, bz...), nil
+}
+
+func (s *MemoryStore) BlockResultsHeights(chainName string) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return sortedHeights(s.blocks[chainName]), nil
+}
+
+func sortedHeights(results map[int64][]byte) []int64 {
+	heights := make([]int64, 0, len(results))
+	for h := range results {
+		heights = append(heights, h)
+	}
+
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+	return heights
+}
+
+func (s *MemoryStore) Codec() codec.Marshaler {
+	return s.cdc
+}
+
+// CreateTicket creates a pending ticket for txHash on chain, like the API server does through store.Store.
+func (s *MemoryStore) CreateTicket(chain, txHash, owner string) error {
+	owner = hex.EncodeToString([]byte(owner))
+	key := store.GetKey(chain, txHash)
+
+	if err := s.createShadowKey(key); err != nil {
+		return err
+	}
+
+	if err := s.SetWithExpiry(key, store.Ticket{Owner: owner, Status: pendingStatus}, 0); err != nil {
+		return err
+	}
+
+	return s.AddMembers(owner, key)
+}
+
+func (s *MemoryStore) SetComplete(key string, height int64) error {
+	ticket, err := s.Get(key)
+	if err != nil {
+		return err
+	}
+
+	if err := s.SetWithExpiry(key, store.Ticket{Status: completeStatus, Height: height}, 2); err != nil {
+		return err
+	}
+
+	return s.untrack(ticket.Owner, key)
+}
+
+func (s *MemoryStore) SetFailedWithErr(key, error string, height int64) error {
+	if !s.Exists(key) {
+		return fmt.Errorf("key doesn't exists")
+	}
+
+	prev, err := s.Get(key)
+	if err != nil {
+		return err
+	}
+
+	if err := s.SetWithExpiry(key, store.Ticket{Height: height, Status: failedStatus, Error: error}, 2); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.unlistTicket(prev.Owner, key)
+	return nil
+}
+
+func (s *MemoryStore) SetInTransit(key, destChain, sourceChannel, sendPacketSequence, txHash, chainName string, height int64) error {
+	if !s.Exists(key) {
+		return fmt.Errorf("key doesn't exists")
+	}
+
+	if err := s.createShadowKey(key); err != nil {
+		return err
+	}
+
+	ticket, err := s.Get(key)
+	if err != nil {
+		return err
+	}
+
+	ticket.Status = transitStatus
+	if err := s.SetWithExpiry(key, ticket, 2); err != nil {
+		return err
+	}
+
+	return s.SetWithExpiry(store.GetIBCKey(destChain, sourceChannel, sendPacketSequence), store.Ticket{
+		Info:  key,
+		Owner: ticket.Owner,
+		TxHashes: []store.TxHashEntry{{
+			Chain:  chainName,
+			Status: transitStatus,
+			TxHash: txHash,
+		}},
+	}, 2)
+}
+
+func (s *MemoryStore) SetIbcReceived(key, txHash, chainName string, height int64) error {
+	return s.resolveIBC(key, txHash, chainName, ibcReceiveSuccessStatus, height)
+}
+
+func (s *MemoryStore) SetIbcTimeoutUnlock(key, txHash, chainName string, height int64) error {
+	return s.resolveIBC(key, txHash, chainName, tokensUnlockedTimeoutStatus, height)
+}
+
+func (s *MemoryStore) SetIbcAckUnlock(key, txHash, chainName string, height int64) error {
+	return s.resolveIBC(key, txHash, chainName, tokensUnlockedAckStatus, height)
+}
+
+func (s *MemoryStore) SetIbcFailed(key, txHash, chainName string, height int64) error {
+	prev, err := s.Get(key)
+	if err != nil {
+		return err
+	}
+
+	if err := s.createShadowKey(prev.Info); err != nil {
+		return err
+	}
+
+	return s.SetWithExpiry(prev.Info, store.Ticket{
+		Status:   ibcReceiveFailedStatus,
+		TxHashes: appendTxHash(prev.TxHashes, chainName, ibcReceiveFailedStatus, txHash),
+		Height:   height,
+	}, 0)
+}
+
+// resolveIBC sets the ticket the IBC packet stored at key originates from to the final status.
+func (s *MemoryStore) resolveIBC(key, txHash, chainName, status string, height int64) error {
+	prev, err := s.Get(key)
+	if err != nil {
+		return err
+	}
+
+	if err := s.SetWithExpiry(prev.Info, store.Ticket{
+		Status:   status,
+		TxHashes: appendTxHash(prev.TxHashes, chainName, status, txHash),
+		Height:   height,
+	}, 2); err != nil {
+		return err
+	}
+
+	return s.untrack(prev.Owner, prev.Info)
+}
+
+func appendTxHash(txHashes []store.TxHashEntry, chainName, status, txHash string) []store.TxHashEntry {
+	return append(txHashes, store.TxHashEntry{
+		Chain:  chainName,
+		Status: status,
+		TxHash: txHash,
+	})
+}
+
+func (s *MemoryStore) SetPoolSwapFees(poolId, offerCoinAmount, offerCoinDenom string) error {
+	amount, ok := sdktypes.NewIntFromString(offerCoinAmount)
+	if !ok {
+		return fmt.Errorf("unable to convert offerCoinAmout to sdk Int")
+	}
+
+	coin := sdktypes.NewCoin(offerCoinDenom, amount)
+	return s.SetWithExpiry(fmt.Sprintf(poolSwapFeesKeyFmt, poolId, time.Now().Unix()), coin.String(), poolSwapFeesExpiryMul)
+}
+
+func (s *MemoryStore) createShadowKey(key string) error {
+	return s.SetWithExpiry(shadowKeyPrefix+key, "", 1)
+}
+
+// untrack deletes the shadow key of the ticket stored at key, and removes it from the tickets of owner.
+func (s *MemoryStore) untrack(owner, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, shadowKeyPrefix+key)
+	s.unlistTicket(owner, key)
+	return nil
+}
+
+// unlistTicket removes the ticket stored at key from the tickets of owner.
+// Callers must hold s.mu.
+func (s *MemoryStore) unlistTicket(owner, key string) {
+	if e, ok := s.entry(owner); ok && e.set != nil {
+		delete(e.set, key)
+	}
+}
@@ -0,0 +1,55 @@
+package rpcwatcher
+
+import (
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+const nftTransferPort = "nft-transfer"
+
+var ics721App = ibcApp{
+	name:     "nft",
+	port:     nftTransferPort,
+	isPort:   func(port string) bool { return port == nftTransferPort },
+	ackError: packetAckError("non_fungible_token_packet"),
+}
+
+// packetSrcPortKeys holds the event keys carrying the source port of an IBC packet, for each
+// stage of its lifecycle.
+var packetSrcPortKeys = []string{
+	"send_packet.packet_src_port",
+	"recv_packet.packet_src_port",
+	"acknowledge_packet.packet_src_port",
+	"timeout_packet.packet_src_port",
+}
+
+// isNFTTransfer returns true if any IBC packet event in data has been emitted on the ICS-721 port.
+func isNFTTransfer(data coretypes.ResultEvent) bool {
+	for _, k := range packetSrcPortKeys {
+		port, ok := data.Events[k]
+		if ok && len(port) > 0 && port[0] == nftTransferPort {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HandleNFTTransfer dispatches an ICS-721 transaction to the handler matching the packet
+// lifecycle stage it carries.
+func HandleNFTTransfer(w *Watcher, data coretypes.ResultEvent, chainName, txHash, key string, height int64) {
+	_, sendPacketEventPresent := data.Events["send_packet.packet_sequence"]
+	_, recvPacketEventPresent := data.Events["recv_packet.packet_sequence"]
+	_, timeoutPacketEventPresent := data.Events["timeout_packet.packet_sequence"]
+	_, ackPacketEventPresent := data.Events["acknowledge_packet.packet_sequence"]
+
+	switch {
+	case sendPacketEventPresent:
+		handleIBCSend(w, ics721App, data, chainName, txHash, key, height)
+	case recvPacketEventPresent:
+		handleIBCReceive(w, ics721App, data, chainName, txHash, height)
+	case timeoutPacketEventPresent:
+		handleIBCTimeout(w, ics721App, data, chainName, txHash, height)
+	case ackPacketEventPresent:
+		handleIBCAck(w, ics721App, data, chainName, txHash, height)
+	}
+}
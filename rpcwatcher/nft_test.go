@@ -0,0 +1,121 @@
+package rpcwatcher
+
+import (
+	"testing"
+
+	"github.com/emerishq/emeris-rpcwatcher/rpcwatcher/database"
+	"github.com/emerishq/emeris-utils/store"
+	"github.com/stretchr/testify/require"
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+func TestIsNFTTransfer(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     coretypes.ResultEvent
+		expValue bool
+	}{
+		{
+			"empty data",
+			coretypes.ResultEvent{},
+			false,
+		},
+		{
+			"fungible token transfer",
+			ibcTransferEvent(t),
+			false,
+		},
+		{
+			"non fungible token transfer",
+			asNFTTransferEvent(ibcTransferEvent(t)),
+			true,
+		},
+		{
+			"non fungible token timeout",
+			asNFTTransferEvent(ibcTimeoutEvent(t)),
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expValue, isNFTTransfer(tt.data))
+		})
+	}
+}
+
+func TestHandleNFTTransfer(t *testing.T) {
+	watcherInstance := &Watcher{
+		l:    logger,
+		d:    dbInstance,
+		Name: database.TestChainName,
+	}
+
+	tests := []struct {
+		name      string
+		data      coretypes.ResultEvent
+		txHash    func() string
+		eventType string
+		expStatus string
+	}{
+		{
+			"Handle nft send transaction",
+			asNFTTransferEvent(ibcTransferEvent(t)),
+			func() string { return ibcTransferTxHash },
+			"",
+			"transit",
+		},
+		{
+			"Handle nft receive packet successful transaction",
+			asNFTTransferEvent(ibcReceivePacketEvent(t, true)),
+			func() string { return ibcReceiveTxHash },
+			"recv_packet",
+			"IBC_receive_success",
+		},
+		{
+			"Handle nft receive packet failed transaction",
+			asNFTTransferEvent(ibcReceivePacketEvent(t, false)),
+			func() string { return ibcReceiveTxHash },
+			"recv_packet",
+			"IBC_receive_failed",
+		},
+		{
+			"Handle nft ack packet with token packet error",
+			asNFTTransferEvent(ibcAckTxEvent(t, true)),
+			func() string { return ibcAckTxHash },
+			"acknowledge_packet",
+			"Tokens_unlocked_ack",
+		},
+		{
+			"Handle nft ack packet without token packet error",
+			asNFTTransferEvent(ibcAckTxEvent(t, false)),
+			func() string { return ibcAckTxHash },
+			"acknowledge_packet",
+			"transit",
+		},
+		{
+			"Handle nft timeout packet transaction",
+			asNFTTransferEvent(ibcTimeoutEvent(t)),
+			func() string { return ibcTimeoutTxHash },
+			"timeout_packet",
+			"Tokens_unlocked_timeout",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := NewMemoryStore()
+			watcherInstance.store = ms
+			txHash := tt.txHash()
+			require.NoError(t, ms.CreateTicket(watcherInstance.Name, txHash, testOwner))
+			key := store.GetKey(database.TestChainName, txHash)
+			if tt.eventType != "" {
+				checkAndSetInTransit(t, tt.data, watcherInstance, txHash, tt.eventType, key)
+			}
+			HandleMessage(watcherInstance, tt.data)
+			ticket, err := ms.Get(key)
+			require.NoError(t, err)
+			require.Equal(t, tt.expStatus, ticket.Status)
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package rpcwatcher
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	cnsmodels "github.com/emerishq/demeris-backend-models/cns"
+	"github.com/emerishq/emeris-utils/store"
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+const (
+	osmosisPoolStateKeyFmt = "pool_state/%s/%s"
+	osmosisShareDenomFmt   = "gamm/pool/%s"
+
+	osmosisTypePoolCreation = "pool_creation"
+	osmosisTypeJoinPool     = "join_pool"
+	osmosisTypeExitPool     = "exit_pool"
+	osmosisTypeSwap         = "swap"
+
+	// gamm pool shares are minted with 18 decimals
+	osmosisSharePrecision = 18
+)
+
+// osmosisPoolEvents maps the gamm and poolmanager events carrying a pool id to the ticket type of the
+// transaction emitting them, by order of precedence.
+var osmosisPoolEvents = []struct {
+	event  string
+	txType string
+}{
+	{"pool_created", osmosisTypePoolCreation},
+	{"pool_joined", osmosisTypeJoinPool},
+	{"pool_exited", osmosisTypeExitPool},
+	{"token_swapped", osmosisTypeSwap},
+}
+
+// osmosisPoolQueryPaths holds the LCD routes serving the state of a pool, poolmanager first.
+var osmosisPoolQueryPaths = []string{
+	"osmosis/poolmanager/v1beta1/pools/%s",
+	"osmosis/gamm/v1beta1/pools/%s",
+}
+
+// OsmosisPoolAction describes an Osmosis pool creation, join, exit or swap, as recorded on its ticket.
+type OsmosisPoolAction struct {
+	Type      string   `json:"type"`
+	PoolIDs   []string `json:"pool_ids"`
+	TokensIn  []string `json:"tokens_in,omitempty"`
+	TokensOut []string `json:"tokens_out,omitempty"`
+}
+
+// parseLCDEndpoints returns by chain the LCD endpoints configured as "<chain>=<url>" entries.
+func parseLCDEndpoints(entries []string) (map[string]string, error) {
+	ret := map[string]string{}
+	for _, e := range entries {
+		parts := strings.SplitN(e, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid lcd endpoint %s, expected <chain>=<url>", e)
+		}
+
+		u, err := url.Parse(parts[1])
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid lcd endpoint url %s for chain %s", parts[1], parts[0])
+		}
+
+		ret[parts[0]] = parts[1]
+	}
+
+	return ret, nil
+}
+
+// SetLCDEndpoint sets the LCD endpoint the state of the pools of the watched chain is queried from.
+// Pool states aren't cached when endpoint is empty.
+func (w *Watcher) SetLCDEndpoint(endpoint string) {
+	w.lcdEndpoint = endpoint
+}
+
+// osmosisPoolAction returns the pool action carried by data, if any.
+func osmosisPoolAction(data coretypes.ResultEvent) (OsmosisPoolAction, bool) {
+	for _, e := range osmosisPoolEvents {
+		poolIDs, ok := data.Events[e.event+".pool_id"]
+		if !ok {
+			continue
+		}
+
+		return OsmosisPoolAction{
+			Type:      e.txType,
+			PoolIDs:   poolIDs,
+			TokensIn:  data.Events[e.event+".tokens_in"],
+			TokensOut: data.Events[e.event+".tokens_out"],
+		}, true
+	}
+
+	return OsmosisPoolAction{}, false
+}
+
+// isConcentratedPoolCreation returns true if data creates a concentrated liquidity pool, whose
+// positions aren't represented by a share denom.
+func isConcentratedPoolCreation(data coretypes.ResultEvent) bool {
+	for _, action := range data.Events["message.action"] {
+		if strings.Contains(action, "concentratedliquidity") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HandleOsmosisMessage handles the transactions of chains running Osmosis gamm and poolmanager
+// modules, registering new pool share denoms and caching the state of the pools involved.
+// Any other transaction is handled by HandleMessage.
+func HandleOsmosisMessage(w *Watcher, data coretypes.ResultEvent) {
+	action, isPoolAction := osmosisPoolAction(data)
+	txHashSlice := data.Events["tx.hash"]
+
+	eventTx, ok := data.Data.(types.EventDataTx)
+	if !ok || eventTx.Result.Code != 0 || len(txHashSlice) == 0 || !isPoolAction {
+		HandleMessage(w, data)
+		return
+	}
+
+	chainName := w.Name
+	height := eventTx.Height
+	key := store.GetKey(chainName, txHashSlice[0])
+
+	if action.Type == osmosisTypePoolCreation && !isConcentratedPoolCreation(data) {
+		HandleOsmosisPoolCreated(w, chainName, action.PoolIDs[0])
+	}
+
+	go cacheOsmosisPools(w, chainName, action.PoolIDs)
+
+	if !w.store.Exists(key) {
+		w.l.Debugw("bypassing key, event not sourced from us", "chain_name", w.Name, "key", key, "event", action.Type)
+		return
+	}
+
+	if err := w.store.SetComplete(key, height); err != nil {
+		w.l.Errorw("cannot set complete", "chain name", chainName, "error", err)
+		return
+	}
+
+	if err := setTicketFields(w.store, key, struct {
+		Osmosis OsmosisPoolAction `json:"osmosis"`
+	}{action}); err != nil {
+		w.l.Errorw("cannot set osmosis pool action on ticket", "key", key, "error", err)
+	}
+
+	w.recordTxInfo(key, data)
+	w.recordTransition(key, chainName, height, data.Events)
+}
+
+// HandleOsmosisPoolCreated registers the share denom of a new gamm pool on chainName.
+// Osmosis pools are permissionless, so share denoms are left unverified.
+func HandleOsmosisPoolCreated(w *Watcher, chainName, poolID string) {
+	err := w.d.AddDenoms(chainName, cnsmodels.Denom{
+		Name:        fmt.Sprintf(osmosisShareDenomFmt, poolID),
+		DisplayName: fmt.Sprintf("Osmosis %s", poolID),
+		Ticker:      fmt.Sprintf("GAMM-%s", poolID),
+		Precision:   osmosisSharePrecision,
+	})
+	if err != nil {
+		w.l.Errorw("failed to update chain", "chain_name", chainName, "error", err)
+	}
+}
+
+// cacheOsmosisPools stores the state of the pools poolIDs, as served by the chain LCD.
+func cacheOsmosisPools(w *Watcher, chainName string, poolIDs []string) {
+	if w.lcdEndpoint == "" {
+		w.l.Debugw("no lcd endpoint configured, not caching pool state", "chain_name", chainName, "pool_ids", poolIDs)
+		return
+	}
+
+	for _, poolID := range poolIDs {
+		cacheOsmosisPool(w, chainName, poolID)
+	}
+}
+
+// cacheOsmosisPool stores the state of poolID, as served by the chain LCD.
+func cacheOsmosisPool(w *Watcher, chainName, poolID string) {
+	for _, p := range osmosisPoolQueryPaths {
+		u, err := url.Parse(w.lcdEndpoint)
+		if err != nil {
+			w.l.Errorw("cannot parse lcd endpoint", "url_string", w.lcdEndpoint, "error", err)
+			return
+		}
+
+		u.Path = fmt.Sprintf(p, poolID)
+
+		resp, err := w.rpcClient.Get(u.String())
+		if err != nil {
+			w.l.Errorw("cannot query pool state", "pool_id", poolID, "error", err)
+			return
+		}
+
+		res := bytes.Buffer{}
+		_, err = res.ReadFrom(resp.Body)
+		_ = resp.Body.Close()
+
+		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusNotImplemented {
+			continue
+		}
+
+		if err != nil || resp.StatusCode != http.StatusOK {
+			w.l.Errorw("cannot read pool state", "pool_id", poolID, "code", resp.StatusCode, "error", err)
+			return
+		}
+
+		if err := w.store.SetWithExpiry(fmt.Sprintf(osmosisPoolStateKeyFmt, chainName, poolID), res.String(), 0); err != nil {
+			w.l.Errorw("cannot set pool state", "pool_id", poolID, "error", err)
+		}
+
+		return
+	}
+
+	w.l.Errorw("no route serves pool state", "chain_name", chainName, "pool_id", poolID)
+}
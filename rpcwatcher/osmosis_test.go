@@ -0,0 +1,224 @@
+package rpcwatcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/emerishq/emeris-rpcwatcher/rpcwatcher/database"
+	"github.com/emerishq/emeris-utils/store"
+	"github.com/stretchr/testify/require"
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+const testPoolState = `{"pool":{"@type":"/osmosis.gamm.v1beta1.Pool","id":"%s"}}`
+
+// osmosisEvent returns a transaction event carrying the given message action and gamm events.
+func osmosisEvent(t *testing.T, action string, events map[string][]string) coretypes.ResultEvent {
+	event := nonIBCTransferEvent(t, true)
+	event.Events["message.action"] = []string{action}
+	for k, v := range events {
+		event.Events[k] = v
+	}
+
+	return event
+}
+
+func TestParseLCDEndpoints(t *testing.T) {
+	tests := []struct {
+		name         string
+		entries      []string
+		expEndpoints map[string]string
+		expErr       bool
+	}{
+		{
+			"endpoints by chain",
+			[]string{"osmosis=http://osmosis-lcd:1317", "osmosis-testnet=https://lcd.testnet.osmosis.zone"},
+			map[string]string{
+				"osmosis":         "http://osmosis-lcd:1317",
+				"osmosis-testnet": "https://lcd.testnet.osmosis.zone",
+			},
+			false,
+		},
+		{
+			"no endpoints",
+			nil,
+			map[string]string{},
+			false,
+		},
+		{
+			"missing chain",
+			[]string{"http://osmosis-lcd:1317"},
+			nil,
+			true,
+		},
+		{
+			"missing scheme",
+			[]string{"osmosis=osmosis-lcd:1317"},
+			nil,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoints, err := parseLCDEndpoints(tt.entries)
+			if tt.expErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expEndpoints, endpoints)
+		})
+	}
+}
+
+func TestOsmosisPoolAction(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      coretypes.ResultEvent
+		expAction OsmosisPoolAction
+		expOk     bool
+	}{
+		{
+			"non pool transaction",
+			nonIBCTransferEvent(t, true),
+			OsmosisPoolAction{},
+			false,
+		},
+		{
+			"pool creation",
+			osmosisEvent(t, "/osmosis.gamm.poolmodels.balancer.v1beta1.MsgCreateBalancerPool", map[string][]string{
+				"pool_created.pool_id": {"1"},
+				"pool_joined.pool_id":  {"1"},
+			}),
+			OsmosisPoolAction{Type: osmosisTypePoolCreation, PoolIDs: []string{"1"}},
+			true,
+		},
+		{
+			"multi hop swap",
+			osmosisEvent(t, "/osmosis.poolmanager.v1beta1.MsgSwapExactAmountIn", map[string][]string{
+				"token_swapped.pool_id":    {"1", "2"},
+				"token_swapped.tokens_in":  {"1000uosmo", "500uion"},
+				"token_swapped.tokens_out": {"500uion", "250uatom"},
+			}),
+			OsmosisPoolAction{
+				Type:      osmosisTypeSwap,
+				PoolIDs:   []string{"1", "2"},
+				TokensIn:  []string{"1000uosmo", "500uion"},
+				TokensOut: []string{"500uion", "250uatom"},
+			},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, ok := osmosisPoolAction(tt.data)
+			require.Equal(t, tt.expOk, ok)
+			require.Equal(t, tt.expAction, action)
+		})
+	}
+}
+
+func TestHandleOsmosisMessage(t *testing.T) {
+	lcd := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var poolID string
+		if _, err := fmt.Sscanf(r.URL.Path, "/osmosis/gamm/v1beta1/pools/%s", &poolID); err != nil {
+			w.WriteHeader(http.StatusNotImplemented)
+			return
+		}
+
+		_, _ = fmt.Fprintf(w, testPoolState, poolID)
+	}))
+	defer lcd.Close()
+
+	watcherInstance := &Watcher{
+		l:           logger,
+		d:           dbInstance,
+		store:       NewRedisStore(s),
+		Name:        database.TestChainName,
+		lcdEndpoint: lcd.URL,
+		rpcClient:   lcd.Client(),
+	}
+
+	tests := []struct {
+		name          string
+		data          coretypes.ResultEvent
+		expStatus     string
+		expPools      []string
+		expShareDenom string
+	}{
+		{
+			"Handle non pool transaction",
+			nonIBCTransferEvent(t, true),
+			"complete",
+			nil,
+			"",
+		},
+		{
+			"Handle join pool",
+			osmosisEvent(t, "/osmosis.gamm.v1beta1.MsgJoinPool", map[string][]string{
+				"pool_joined.pool_id":   {"1"},
+				"pool_joined.tokens_in": {"1000uosmo,500uion"},
+			}),
+			"complete",
+			[]string{"1"},
+			"",
+		},
+		{
+			"Handle multi hop swap",
+			osmosisEvent(t, "/osmosis.poolmanager.v1beta1.MsgSwapExactAmountOut", map[string][]string{
+				"token_swapped.pool_id":    {"1", "2"},
+				"token_swapped.tokens_in":  {"1000uosmo", "500uion"},
+				"token_swapped.tokens_out": {"500uion", "250uatom"},
+			}),
+			"complete",
+			[]string{"1", "2"},
+			"",
+		},
+		{
+			"Handle pool creation",
+			osmosisEvent(t, "/osmosis.gamm.poolmodels.balancer.v1beta1.MsgCreateBalancerPool", map[string][]string{
+				"pool_created.pool_id": {"42"},
+			}),
+			"complete",
+			[]string{"42"},
+			"gamm/pool/42",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer store.ResetTestStore(mr, s)
+			require.NoError(t, s.CreateTicket(watcherInstance.Name, nonIBCTransferTxHash, testOwner))
+			key := store.GetKey(database.TestChainName, nonIBCTransferTxHash)
+
+			HandleOsmosisMessage(watcherInstance, tt.data)
+
+			ticket, err := s.Get(key)
+			require.NoError(t, err)
+			require.Equal(t, tt.expStatus, ticket.Status)
+
+			// pool states are cached in the background
+			for _, poolID := range tt.expPools {
+				poolKey := fmt.Sprintf(osmosisPoolStateKeyFmt, watcherInstance.Name, poolID)
+				require.Eventually(t, func() bool {
+					return s.Exists(poolKey)
+				}, time.Second, 10*time.Millisecond)
+
+				state, err := s.Client.Get(context.Background(), poolKey).Result()
+				require.NoError(t, err)
+				require.Equal(t, fmt.Sprintf(testPoolState, poolID), state)
+			}
+
+			if tt.expShareDenom != "" {
+				checkDenomExists(t, watcherInstance, tt.expShareDenom, true)
+			}
+		})
+	}
+}
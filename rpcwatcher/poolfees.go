@@ -0,0 +1,136 @@
+package rpcwatcher
+
+import (
+	"fmt"
+	"time"
+
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	liquiditytypes "github.com/gravity-devs/liquidity/x/liquidity/types"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+const (
+	poolFeesKeyFmt = "pool_fees/%s/%s/%d"
+
+	// SwapFeesWindowDay and SwapFeesWindowWeek are the windows over which pool swap fees are usually read.
+	SwapFeesWindowDay  = 24 * time.Hour
+	SwapFeesWindowWeek = 7 * SwapFeesWindowDay
+)
+
+// feeBucket is a time granularity at which pool swap fees are accumulated.
+type feeBucket struct {
+	name      string
+	size      time.Duration
+	retention time.Duration
+}
+
+// feeBuckets holds the granularities pool swap fees are accumulated at, finest first: hourly buckets
+// are kept long enough to serve a week of fees, daily buckets serve longer windows.
+var feeBuckets = []feeBucket{
+	{name: "hour", size: time.Hour, retention: 8 * 24 * time.Hour},
+	{name: "day", size: 24 * time.Hour, retention: 90 * 24 * time.Hour},
+}
+
+func (b feeBucket) key(poolID string, start time.Time) string {
+	return fmt.Sprintf(poolFeesKeyFmt, poolID, b.name, start.Unix())
+}
+
+// recordPoolSwapFees accumulates the swap fees charged by the swap_transacted end-block events of a
+// block produced at blockTime.
+func recordPoolSwapFees(w *Watcher, events []abci.Event, blockTime time.Time) {
+	for _, e := range events {
+		if e.Type != liquiditytypes.EventTypeSwapTransacted {
+			continue
+		}
+
+		attrs := map[string]string{}
+		for _, a := range e.Attributes {
+			attrs[string(a.Key)] = string(a.Value)
+		}
+
+		if attrs[liquiditytypes.AttributeValueSuccess] != liquiditytypes.Success {
+			continue
+		}
+
+		fees, err := swapFees(attrs)
+		if err != nil {
+			w.l.Errorw("cannot read swap fees", "pool_id", attrs[liquiditytypes.AttributeValuePoolId], "error", err)
+			continue
+		}
+
+		if err := addPoolSwapFees(w.store, attrs[liquiditytypes.AttributeValuePoolId], fees, blockTime); err != nil {
+			w.l.Errorw("cannot accumulate pool swap fees", "pool_id", attrs[liquiditytypes.AttributeValuePoolId], "error", err)
+		}
+	}
+}
+
+// swapFees returns the fees charged on both sides of a matched swap order.
+func swapFees(attrs map[string]string) (sdktypes.Coins, error) {
+	fees := sdktypes.NewCoins()
+	for amountAttr, denomAttr := range map[string]string{
+		liquiditytypes.AttributeValueOfferCoinFeeAmount:     liquiditytypes.AttributeValueOfferCoinDenom,
+		liquiditytypes.AttributeValueExchangedCoinFeeAmount: liquiditytypes.AttributeValueDemandCoinDenom,
+	} {
+		amount, ok := sdktypes.NewIntFromString(attrs[amountAttr])
+		if !ok || amount.IsNegative() {
+			return nil, fmt.Errorf("invalid %s %s", amountAttr, attrs[amountAttr])
+		}
+
+		if err := sdktypes.ValidateDenom(attrs[denomAttr]); err != nil {
+			return nil, err
+		}
+
+		fees = fees.Add(sdktypes.NewCoin(attrs[denomAttr], amount))
+	}
+
+	return fees, nil
+}
+
+// addPoolSwapFees adds fees to the buckets of every granularity covering at.
+func addPoolSwapFees(s TicketStore, poolID string, fees sdktypes.Coins, at time.Time) error {
+	if fees.IsZero() {
+		return nil
+	}
+
+	for _, b := range feeBuckets {
+		start := at.UTC().Truncate(b.size)
+		key := b.key(poolID, start)
+
+		if err := s.AddCoins(key, fees, start.Add(b.size+b.retention)); err != nil {
+			return fmt.Errorf("cannot update %s bucket %s, %w", b.name, key, err)
+		}
+	}
+
+	return nil
+}
+
+// PoolSwapFees returns the swap fees earned by poolID over the window ending at now, such as
+// SwapFeesWindowDay or SwapFeesWindowWeek.
+// Windows are rounded to whole buckets, the current one included: hourly buckets are used as long as
+// they're retained, daily ones afterwards.
+func PoolSwapFees(s TicketStore, poolID string, window time.Duration, now time.Time) (sdktypes.Coins, error) {
+	b := feeBuckets[len(feeBuckets)-1]
+	for _, fb := range feeBuckets {
+		if window <= fb.retention {
+			b = fb
+			break
+		}
+	}
+
+	if window > b.retention {
+		return nil, fmt.Errorf("window %s exceeds swap fees retention of %s", window, b.retention)
+	}
+
+	current := now.UTC().Truncate(b.size)
+	total := sdktypes.NewCoins()
+	for i := int64(0); i < int64(window/b.size); i++ {
+		fees, err := s.Coins(b.key(poolID, current.Add(-time.Duration(i)*b.size)))
+		if err != nil {
+			return nil, err
+		}
+
+		total = total.Add(fees...)
+	}
+
+	return total, nil
+}
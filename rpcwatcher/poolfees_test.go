@@ -0,0 +1,147 @@
+package rpcwatcher
+
+import (
+	"testing"
+	"time"
+
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	"github.com/emerishq/emeris-rpcwatcher/rpcwatcher/database"
+	"github.com/emerishq/emeris-utils/store"
+	liquiditytypes "github.com/gravity-devs/liquidity/x/liquidity/types"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+func TestSwapFees(t *testing.T) {
+	tests := []struct {
+		name    string
+		attrs   map[string]string
+		expFees sdktypes.Coins
+		expErr  bool
+	}{
+		{
+			"matched order",
+			swapTransactedAttrs("23941", liquiditytypes.Success, "0", "100"),
+			sdktypes.NewCoins(
+				sdktypes.NewInt64Coin(testOfferDenom, 1500000),
+				sdktypes.NewInt64Coin("uatom", 120000),
+			),
+			false,
+		},
+		{
+			"invalid fee amount",
+			map[string]string{
+				"offer_coin_denom":          testOfferDenom,
+				"offer_coin_fee_amount":     "invalid",
+				"demand_coin_denom":         "uatom",
+				"exchanged_coin_fee_amount": "120000",
+			},
+			nil,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fees, err := swapFees(tt.attrs)
+			if tt.expErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expFees, fees)
+		})
+	}
+}
+
+func TestPoolSwapFees(t *testing.T) {
+	defer store.ResetTestStore(mr, s)
+
+	watcherInstance := &Watcher{
+		l:     logger,
+		d:     dbInstance,
+		store: NewRedisStore(s),
+		Name:  database.TestChainName,
+	}
+
+	now := time.Now().UTC()
+	matched := batchEvent(liquiditytypes.EventTypeSwapTransacted, swapTransactedAttrs("23941", liquiditytypes.Success, "0", "100"))
+	notMatched := batchEvent(liquiditytypes.EventTypeSwapTransacted, swapTransactedAttrs("23941", liquiditytypes.Failure, "0", "100"))
+
+	recordPoolSwapFees(watcherInstance, []abci.Event{matched, notMatched}, now)
+	recordPoolSwapFees(watcherInstance, []abci.Event{matched}, now.Add(-3*time.Hour))
+	recordPoolSwapFees(watcherInstance, []abci.Event{matched}, now.Add(-3*SwapFeesWindowDay))
+	recordPoolSwapFees(watcherInstance, []abci.Event{matched}, now.Add(-10*SwapFeesWindowDay))
+
+	fees := func(n int64) sdktypes.Coins {
+		return sdktypes.NewCoins(
+			sdktypes.NewInt64Coin(testOfferDenom, n*1500000),
+			sdktypes.NewInt64Coin("uatom", n*120000),
+		)
+	}
+
+	tests := []struct {
+		name    string
+		poolID  string
+		window  time.Duration
+		expFees sdktypes.Coins
+		expErr  bool
+	}{
+		{
+			"current hour",
+			"5",
+			time.Hour,
+			fees(1),
+			false,
+		},
+		{
+			"last day",
+			"5",
+			SwapFeesWindowDay,
+			fees(2),
+			false,
+		},
+		{
+			"last week",
+			"5",
+			SwapFeesWindowWeek,
+			fees(3),
+			false,
+		},
+		{
+			"last month",
+			"5",
+			30 * SwapFeesWindowDay,
+			fees(4),
+			false,
+		},
+		{
+			"unknown pool",
+			"6",
+			SwapFeesWindowWeek,
+			sdktypes.NewCoins(),
+			false,
+		},
+		{
+			"window beyond retention",
+			"5",
+			365 * SwapFeesWindowDay,
+			nil,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fees, err := PoolSwapFees(NewRedisStore(s), tt.poolID, tt.window, now)
+			if tt.expErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expFees.String(), fees.String())
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package rpcwatcher
+
+import (
+	cnsmodels "github.com/emerishq/demeris-backend-models/cns"
+)
+
+// ChainRegistry is where watchers read chains from and record the denoms they discover.
+// database.Instance reads CNS, database.FileRegistry a static chain registry.
+type ChainRegistry interface {
+	Chain(chainName string) (cnsmodels.Chain, error)
+	Chains() ([]cnsmodels.Chain, error)
+	GetCounterParty(chainName, srcChannel string) ([]cnsmodels.ChannelQuery, error)
+	UpdateDenoms(chain cnsmodels.Chain) error
+	UpsertDenom(chainName string, denom cnsmodels.Denom) error
+	AddDenoms(chainName string, denoms ...cnsmodels.Denom) error
+}
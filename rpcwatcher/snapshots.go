@@ -0,0 +1,220 @@
+package rpcwatcher
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"google.golang.org/grpc"
+
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	minttypes "github.com/cosmos/cosmos-sdk/x/mint/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	liquiditytypes "github.com/gravity-devs/liquidity/x/liquidity/types"
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+const (
+	chainStateKeyFmt = "chain_state/%s/%s"
+
+	// defaultSnapshotInterval is the number of blocks between two snapshots of a chain state
+	defaultSnapshotInterval = 1
+
+	// defaultSnapshotQueryTimeout bounds the time spent waiting for the response of a snapshot query
+	defaultSnapshotQueryTimeout = 10 * time.Second
+
+	// legacySnapshotChain is the chain whose liquidity and supply state the API server still reads from
+	// the keys of legacySnapshotKeys
+	legacySnapshotChain = "cosmos-hub"
+)
+
+// legacySnapshotKeys maps the snapshot queries of legacySnapshotChain to the global keys their
+// responses were cached at before chain state snapshots, read by store.GetPools, store.GetParams and
+// store.GetSupply.
+var legacySnapshotKeys = map[string]string{
+	"liquidity_pools":  "pools",
+	"liquidity_params": "params",
+	"supply":           "supply",
+}
+
+// snapshotQuery performs a gRPC query whose response is cached as part of a chain state snapshot.
+type snapshotQuery func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error)
+
+// snapshotQueries holds the gRPC queries which can be enabled per chain, by name.
+var snapshotQueries = map[string]snapshotQuery{
+	"liquidity_pools": func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+		return liquiditytypes.NewQueryClient(conn).LiquidityPools(ctx, &liquiditytypes.QueryLiquidityPoolsRequest{})
+	},
+	"liquidity_params": func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+		return liquiditytypes.NewQueryClient(conn).Params(ctx, &liquiditytypes.QueryParamsRequest{})
+	},
+	"supply": func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+		return banktypes.NewQueryClient(conn).TotalSupply(ctx, &banktypes.QueryTotalSupplyRequest{})
+	},
+	"staking_pool": func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+		return stakingtypes.NewQueryClient(conn).Pool(ctx, &stakingtypes.QueryPoolRequest{})
+	},
+	"staking_params": func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+		return stakingtypes.NewQueryClient(conn).Params(ctx, &stakingtypes.QueryParamsRequest{})
+	},
+	"validators": func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+		return stakingtypes.NewQueryClient(conn).Validators(ctx, &stakingtypes.QueryValidatorsRequest{
+			Status: stakingtypes.BondStatusBonded,
+		})
+	},
+	"inflation": func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+		return minttypes.NewQueryClient(conn).Inflation(ctx, &minttypes.QueryInflationRequest{})
+	},
+	"annual_provisions": func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+		return minttypes.NewQueryClient(conn).AnnualProvisions(ctx, &minttypes.QueryAnnualProvisionsRequest{})
+	},
+	"mint_params": func(ctx context.Context, conn *grpc.ClientConn) (proto.Message, error) {
+		return minttypes.NewQueryClient(conn).Params(ctx, &minttypes.QueryParamsRequest{})
+	},
+}
+
+// parseSnapshotQueries groups by chain the snapshot queries configured as "<chain>:<query>" entries.
+func parseSnapshotQueries(entries []string) (map[string][]string, error) {
+	ret := map[string][]string{}
+	for _, e := range entries {
+		parts := strings.SplitN(e, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid snapshot query %s, expected <chain>:<query>", e)
+		}
+
+		if _, ok := snapshotQueries[parts[1]]; !ok {
+			return nil, fmt.Errorf("unknown snapshot query %s for chain %s", parts[1], parts[0])
+		}
+
+		ret[parts[0]] = append(ret[parts[0]], parts[1])
+	}
+
+	return ret, nil
+}
+
+func chainStateKey(chainName, query string) string {
+	return fmt.Sprintf(chainStateKeyFmt, chainName, query)
+}
+
+// SetSnapshots sets the gRPC queries whose responses are cached for the watched chain, every interval blocks.
+func (w *Watcher) SetSnapshots(queries []string, interval int64) {
+	w.snapshotQueries = queries
+	w.snapshotInterval = interval
+}
+
+// HandleChainSnapshot caches the state of the watched chain returned by its configured snapshot queries,
+// without blocking the handling of the following events.
+func HandleChainSnapshot(w *Watcher, data coretypes.ResultEvent) {
+	realData, ok := data.Data.(types.EventDataNewBlock)
+	if !ok {
+		panic("rpc returned block data which is not of expected type")
+	}
+
+	if realData.Block == nil || len(chainSnapshotQueries(w)) == 0 || w.snapshotInterval <= 0 {
+		return
+	}
+
+	height := realData.Block.Height
+	if height%w.snapshotInterval != 0 {
+		return
+	}
+
+	// a snapshot still running when the next one is due makes the latter a no-op
+	if !atomic.CompareAndSwapInt32(&w.snapshotting, 0, 1) {
+		return
+	}
+
+	go func() {
+		defer atomic.StoreInt32(&w.snapshotting, 0)
+
+		// creating a grpc ClientConn to perform RPCs
+		grpcConn, err := grpc.Dial(
+			w.grpcEndpoint,
+			grpc.WithInsecure(),
+		)
+		if err != nil {
+			w.l.Errorw("cannot create gRPC client", "error", err, "chain_name", w.Name, "address", w.grpcEndpoint)
+			return
+		}
+
+		defer func() {
+			if err := grpcConn.Close(); err != nil {
+				w.l.Errorw("cannot close gRPC client", "error", err, "chain_name", w.Name)
+			}
+		}()
+
+		snapshotChainState(w, grpcConn, height)
+	}()
+}
+
+// chainSnapshotQueries returns the snapshot queries of the watched chain, which always include the
+// ones of legacySnapshotKeys on legacySnapshotChain.
+func chainSnapshotQueries(w *Watcher) []string {
+	if w.Name != legacySnapshotChain {
+		return w.snapshotQueries
+	}
+
+	queries := append([]string{}, w.snapshotQueries...)
+	for _, name := range []string{"liquidity_pools", "liquidity_params", "supply"} {
+		configured := false
+		for _, q := range queries {
+			if q == name {
+				configured = true
+				break
+			}
+		}
+
+		if !configured {
+			queries = append(queries, name)
+		}
+	}
+
+	return queries
+}
+
+// snapshotChainState runs the snapshot queries of the watched chain over conn, and caches their responses.
+func snapshotChainState(w *Watcher, conn *grpc.ClientConn, height int64) {
+	for _, name := range chainSnapshotQueries(w) {
+		query, ok := snapshotQueries[name]
+		if !ok {
+			w.l.Errorw("unknown snapshot query", "chain_name", w.Name, "query", name)
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), defaultSnapshotQueryTimeout)
+		res, err := query(ctx, conn)
+		cancel()
+		if err != nil {
+			w.l.Errorw("cannot run snapshot query", "chain_name", w.Name, "query", name, "error", err, "height", height)
+			continue
+		}
+
+		bz, err := w.store.Codec().MarshalJSON(res)
+		if err != nil {
+			w.l.Errorw("cannot marshal snapshot query response", "chain_name", w.Name, "query", name, "error", err, "height", height)
+			continue
+		}
+
+		if err := w.store.SetWithExpiry(chainStateKey(w.Name, name), string(bz), 0); err != nil {
+			w.l.Errorw("cannot set snapshot query response", "chain_name", w.Name, "query", name, "error", err, "height", height)
+		}
+
+		legacyKey, ok := legacySnapshotKeys[name]
+		if !ok || w.Name != legacySnapshotChain {
+			continue
+		}
+
+		if err := w.store.SetWithExpiry(legacyKey, string(bz), 0); err != nil {
+			w.l.Errorw("cannot set legacy snapshot query response", "chain_name", w.Name, "query", name, "key", legacyKey, "error", err, "height", height)
+		}
+	}
+}
+
+// ChainState returns the last cached response of the snapshot query of chainName.
+func ChainState(s TicketStore, chainName, query string) ([]byte, error) {
+	return s.Value(chainStateKey(chainName, query))
+}
@@ -0,0 +1,160 @@
+package rpcwatcher
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"github.com/emerishq/emeris-rpcwatcher/rpcwatcher/database"
+	"github.com/emerishq/emeris-utils/store"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type testBankQueryServer struct {
+	*banktypes.UnimplementedQueryServer
+}
+
+func (testBankQueryServer) TotalSupply(context.Context, *banktypes.QueryTotalSupplyRequest) (*banktypes.QueryTotalSupplyResponse, error) {
+	return &banktypes.QueryTotalSupplyResponse{
+		Supply: sdktypes.NewCoins(sdktypes.NewInt64Coin("uatom", 1000)),
+	}, nil
+}
+
+func TestParseSnapshotQueries(t *testing.T) {
+	tests := []struct {
+		name       string
+		entries    []string
+		expQueries map[string][]string
+		expErr     bool
+	}{
+		{
+			"queries grouped by chain",
+			[]string{"cosmos-hub:supply", "osmosis:inflation", "cosmos-hub:staking_pool"},
+			map[string][]string{
+				"cosmos-hub": {"supply", "staking_pool"},
+				"osmosis":    {"inflation"},
+			},
+			false,
+		},
+		{
+			"no queries",
+			nil,
+			map[string][]string{},
+			false,
+		},
+		{
+			"missing chain",
+			[]string{"supply"},
+			nil,
+			true,
+		},
+		{
+			"unknown query",
+			[]string{"cosmos-hub:unknown"},
+			nil,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queries, err := parseSnapshotQueries(tt.entries)
+			if tt.expErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expQueries, queries)
+		})
+	}
+}
+
+func TestSnapshotChainState(t *testing.T) {
+	defer store.ResetTestStore(mr, s)
+
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	banktypes.RegisterQueryServer(srv, testBankQueryServer{})
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(
+		"bufnet",
+		grpc.WithInsecure(),
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return lis.Dial()
+		}),
+	)
+	require.NoError(t, err)
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	watcherInstance := &Watcher{
+		l:     logger,
+		store: NewRedisStore(s),
+		Name:  database.TestChainName,
+	}
+	watcherInstance.SetSnapshots([]string{"supply", "inflation"}, 1)
+
+	snapshotChainState(watcherInstance, conn, 10)
+
+	bz, err := ChainState(NewRedisStore(s), database.TestChainName, "supply")
+	require.NoError(t, err)
+
+	var supply banktypes.QueryTotalSupplyResponse
+	require.NoError(t, s.Cdc.UnmarshalJSON(bz, &supply))
+	require.Equal(t, "1000uatom", supply.Supply.String())
+
+	// the mint module isn't served, so no inflation is cached
+	_, err = ChainState(NewRedisStore(s), database.TestChainName, "inflation")
+	require.Error(t, err)
+
+	// the supply is also cached where the API server reads it from
+	legacySupply, err := s.GetSupply()
+	require.NoError(t, err)
+	require.Equal(t, bz, legacySupply)
+}
+
+func TestChainSnapshotQueries(t *testing.T) {
+	tests := []struct {
+		name       string
+		chainName  string
+		queries    []string
+		expQueries []string
+	}{
+		{
+			"legacy queries always run on cosmos-hub",
+			"cosmos-hub",
+			nil,
+			[]string{"liquidity_pools", "liquidity_params", "supply"},
+		},
+		{
+			"legacy queries aren't repeated",
+			"cosmos-hub",
+			[]string{"supply", "inflation"},
+			[]string{"supply", "inflation", "liquidity_pools", "liquidity_params"},
+		},
+		{
+			"other chains only run their queries",
+			"osmosis",
+			[]string{"supply"},
+			[]string{"supply"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Watcher{Name: tt.chainName}
+			w.SetSnapshots(tt.queries, 1)
+			require.Equal(t, tt.expQueries, chainSnapshotQueries(w))
+		})
+	}
+}
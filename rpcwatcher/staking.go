@@ -0,0 +1,153 @@
+package rpcwatcher
+
+import (
+	"time"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+const (
+	txTypeDelegation           = "delegation"
+	txTypeUndelegation         = "undelegation"
+	txTypeRedelegation         = "redelegation"
+	txTypeRewardWithdrawal     = "reward_withdrawal"
+	txTypeCommissionWithdrawal = "commission_withdrawal"
+	txTypeVote                 = "vote"
+	txTypeProposalDeposit      = "proposal_deposit"
+)
+
+// typedActions maps the message.action attribute of staking, distribution and governance messages
+// to the ticket type they complete, both in their legacy and type URL form.
+var typedActions = map[string]string{
+	"delegate":                      txTypeDelegation,
+	"begin_unbonding":               txTypeUndelegation,
+	"begin_redelegate":              txTypeRedelegation,
+	"withdraw_delegator_reward":     txTypeRewardWithdrawal,
+	"withdraw_validator_commission": txTypeCommissionWithdrawal,
+	"vote":                          txTypeVote,
+	"weighted_vote":                 txTypeVote,
+	"deposit":                       txTypeProposalDeposit,
+
+	"/cosmos.staking.v1beta1.MsgDelegate":                         txTypeDelegation,
+	"/cosmos.staking.v1beta1.MsgUndelegate":                       txTypeUndelegation,
+	"/cosmos.staking.v1beta1.MsgBeginRedelegate":                  txTypeRedelegation,
+	"/cosmos.distribution.v1beta1.MsgWithdrawDelegatorReward":     txTypeRewardWithdrawal,
+	"/cosmos.distribution.v1beta1.MsgWithdrawValidatorCommission": txTypeCommissionWithdrawal,
+	"/cosmos.gov.v1beta1.MsgVote":                                 txTypeVote,
+	"/cosmos.gov.v1beta1.MsgVoteWeighted":                         txTypeVote,
+	"/cosmos.gov.v1beta1.MsgDeposit":                              txTypeProposalDeposit,
+}
+
+// TypedCompletion describes the outcome of a staking, distribution or governance transaction, as
+// recorded on its ticket.
+type TypedCompletion struct {
+	Type                  string      `json:"type"`
+	Amounts               []string    `json:"amounts,omitempty"`
+	Validators            []string    `json:"validators,omitempty"`
+	SourceValidators      []string    `json:"source_validators,omitempty"`
+	DestinationValidators []string    `json:"destination_validators,omitempty"`
+	ProposalIDs           []string    `json:"proposal_ids,omitempty"`
+	Options               []string    `json:"options,omitempty"`
+	CompletionTimes       []time.Time `json:"completion_times,omitempty"`
+}
+
+// typedAction returns the ticket type of the first staking, distribution or governance message in data,
+// including the messages executed through authz MsgExec.
+func typedAction(cdc codectypes.AnyUnpacker, data coretypes.ResultEvent) (string, bool) {
+	actions := append([]string{}, data.Events["message.action"]...)
+	if eventTx, ok := data.Data.(types.EventDataTx); ok {
+		if wrapped, err := unwrapTx(cdc, eventTx.Tx); err == nil {
+			actions = append(actions, wrapped.InnerMsgTypes...)
+		}
+	}
+
+	for _, action := range actions {
+		if txType, ok := typedActions[action]; ok {
+			return txType, true
+		}
+	}
+
+	return "", false
+}
+
+// typedCompletion reads the attributes relevant to txType from the module events in data.
+func typedCompletion(w *Watcher, data coretypes.ResultEvent, txType string) TypedCompletion {
+	c := TypedCompletion{Type: txType}
+
+	switch txType {
+	case txTypeDelegation:
+		c.Validators = data.Events["delegate.validator"]
+		c.Amounts = data.Events["delegate.amount"]
+	case txTypeUndelegation:
+		c.Validators = data.Events["unbond.validator"]
+		c.Amounts = data.Events["unbond.amount"]
+		c.CompletionTimes = completionTimes(w, data.Events["unbond.completion_time"])
+	case txTypeRedelegation:
+		c.SourceValidators = data.Events["redelegate.source_validator"]
+		c.DestinationValidators = data.Events["redelegate.destination_validator"]
+		c.Amounts = data.Events["redelegate.amount"]
+		c.CompletionTimes = completionTimes(w, data.Events["redelegate.completion_time"])
+	case txTypeRewardWithdrawal:
+		c.Validators = data.Events["withdraw_rewards.validator"]
+		c.Amounts = data.Events["withdraw_rewards.amount"]
+	case txTypeCommissionWithdrawal:
+		c.Amounts = data.Events["withdraw_commission.amount"]
+	case txTypeVote:
+		c.ProposalIDs = data.Events["proposal_vote.proposal_id"]
+		c.Options = data.Events["proposal_vote.option"]
+	case txTypeProposalDeposit:
+		c.ProposalIDs = data.Events["proposal_deposit.proposal_id"]
+		c.Amounts = data.Events["proposal_deposit.amount"]
+	}
+
+	return c
+}
+
+func completionTimes(w *Watcher, values []string) []time.Time {
+	var times []time.Time
+	for _, v := range values {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			w.l.Errorw("cannot parse completion time", "value", v, "error", err)
+			continue
+		}
+
+		times = append(times, t)
+	}
+
+	return times
+}
+
+// HandleTypedTransaction completes the ticket of a staking, distribution or governance transaction,
+// recording its type along with the amounts, validators and proposals involved.
+func HandleTypedTransaction(w *Watcher, data coretypes.ResultEvent, chainName, key, txType string, height int64) {
+	w.l.Debugw("called HandleTypedTransaction", "type", txType)
+	if !w.store.Exists(key) {
+		w.l.Debugw("bypassing key, event not sourced from us", "chain_name", w.Name, "key", key, "event", txType)
+		return
+	}
+
+	if err := w.store.SetComplete(key, height); err != nil {
+		w.l.Errorw("cannot set complete", "chain name", chainName, "error", err)
+		return
+	}
+
+	w.recordTypedCompletion(key, data, txType)
+	w.recordTxInfo(key, data)
+	w.recordTransition(key, chainName, height, data.Events)
+}
+
+// recordTypedCompletion records on the ticket stored at key the completion of the txType messages of data.
+func (w *Watcher) recordTypedCompletion(key string, data coretypes.ResultEvent, txType string) {
+	if !w.store.Exists(key) {
+		return
+	}
+
+	if err := setTicketFields(w.store, key, struct {
+		Completion TypedCompletion `json:"completion"`
+	}{typedCompletion(w, data, txType)}); err != nil {
+		w.l.Errorw("cannot set typed completion on ticket", "key", key, "error", err)
+	}
+}
@@ -0,0 +1,171 @@
+package rpcwatcher
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/emerishq/emeris-rpcwatcher/rpcwatcher/database"
+	"github.com/emerishq/emeris-utils/store"
+	"github.com/stretchr/testify/require"
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+const (
+	testValidator    = "cosmosvaloper1sjllsnramtg3ewxqwwrwjxfgc4n4ef9u2lcnj0"
+	testDstValidator = "cosmosvaloper156gqf9837u7d4c4678yt3rl4ls9c5vuursrrzf"
+)
+
+// typedTransactionEvent returns a transaction event carrying the given message action and module events.
+func typedTransactionEvent(t *testing.T, action string, events map[string][]string) coretypes.ResultEvent {
+	event := nonIBCTransferEvent(t, true)
+	event.Events["message.action"] = []string{action}
+	for k, v := range events {
+		event.Events[k] = v
+	}
+
+	return event
+}
+
+func TestHandleTypedTransaction(t *testing.T) {
+	watcherInstance := &Watcher{
+		l:    logger,
+		d:    dbInstance,
+		Name: database.TestChainName,
+	}
+
+	completionTime := time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		data          coretypes.ResultEvent
+		expCompletion TypedCompletion
+	}{
+		{
+			"Handle delegation",
+			typedTransactionEvent(t, "delegate", map[string][]string{
+				"delegate.validator": {testValidator},
+				"delegate.amount":    {"1000"},
+			}),
+			TypedCompletion{
+				Type:       txTypeDelegation,
+				Validators: []string{testValidator},
+				Amounts:    []string{"1000"},
+			},
+		},
+		{
+			"Handle undelegation",
+			typedTransactionEvent(t, "/cosmos.staking.v1beta1.MsgUndelegate", map[string][]string{
+				"unbond.validator":       {testValidator},
+				"unbond.amount":          {"1000"},
+				"unbond.completion_time": {completionTime.Format(time.RFC3339)},
+			}),
+			TypedCompletion{
+				Type:            txTypeUndelegation,
+				Validators:      []string{testValidator},
+				Amounts:         []string{"1000"},
+				CompletionTimes: []time.Time{completionTime},
+			},
+		},
+		{
+			"Handle redelegation",
+			typedTransactionEvent(t, "begin_redelegate", map[string][]string{
+				"redelegate.source_validator":      {testValidator},
+				"redelegate.destination_validator": {testDstValidator},
+				"redelegate.amount":                {"1000"},
+				"redelegate.completion_time":       {completionTime.Format(time.RFC3339)},
+			}),
+			TypedCompletion{
+				Type:                  txTypeRedelegation,
+				SourceValidators:      []string{testValidator},
+				DestinationValidators: []string{testDstValidator},
+				Amounts:               []string{"1000"},
+				CompletionTimes:       []time.Time{completionTime},
+			},
+		},
+		{
+			"Handle reward withdrawal",
+			typedTransactionEvent(t, "withdraw_delegator_reward", map[string][]string{
+				"withdraw_rewards.validator": {testValidator, testDstValidator},
+				"withdraw_rewards.amount":    {"10uatom", "20uatom"},
+			}),
+			TypedCompletion{
+				Type:       txTypeRewardWithdrawal,
+				Validators: []string{testValidator, testDstValidator},
+				Amounts:    []string{"10uatom", "20uatom"},
+			},
+		},
+		{
+			"Handle vote",
+			typedTransactionEvent(t, "vote", map[string][]string{
+				"proposal_vote.proposal_id": {"42"},
+				"proposal_vote.option":      {"VOTE_OPTION_YES"},
+			}),
+			TypedCompletion{
+				Type:        txTypeVote,
+				ProposalIDs: []string{"42"},
+				Options:     []string{"VOTE_OPTION_YES"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := NewMemoryStore()
+			watcherInstance.store = ms
+			require.NoError(t, ms.CreateTicket(watcherInstance.Name, nonIBCTransferTxHash, testOwner))
+			key := store.GetKey(database.TestChainName, nonIBCTransferTxHash)
+
+			HandleMessage(watcherInstance, tt.data)
+
+			ticket, err := ms.Get(key)
+			require.NoError(t, err)
+			require.Equal(t, "complete", ticket.Status)
+
+			bz, err := ms.Value(key)
+			require.NoError(t, err)
+			var completed struct {
+				Completion TypedCompletion `json:"completion"`
+			}
+			require.NoError(t, json.Unmarshal(bz, &completed))
+			require.Equal(t, tt.expCompletion, completed.Completion)
+		})
+	}
+}
+
+func TestHandleTypedTransactionWithIBCTransfer(t *testing.T) {
+	ms := NewMemoryStore()
+	watcherInstance := &Watcher{
+		l:     logger,
+		d:     dbInstance,
+		store: ms,
+		Name:  database.TestChainName,
+	}
+
+	// a delegation sent along with an IBC transfer is tracked as an IBC transfer
+	data := ibcTransferEvent(t)
+	data.Events["message.action"] = append(data.Events["message.action"], "delegate")
+	data.Events["delegate.validator"] = []string{testValidator}
+	data.Events["delegate.amount"] = []string{"1000"}
+
+	require.NoError(t, ms.CreateTicket(watcherInstance.Name, ibcTransferTxHash, testOwner))
+	key := store.GetKey(database.TestChainName, ibcTransferTxHash)
+
+	HandleMessage(watcherInstance, data)
+
+	ticket, err := ms.Get(key)
+	require.NoError(t, err)
+	require.Equal(t, "transit", ticket.Status)
+
+	bz, err := ms.Value(key)
+	require.NoError(t, err)
+	var completed struct {
+		Completion TypedCompletion `json:"completion"`
+	}
+	require.NoError(t, json.Unmarshal(bz, &completed))
+	require.Equal(t, TypedCompletion{
+		Type:       txTypeDelegation,
+		Validators: []string{testValidator},
+		Amounts:    []string{"1000"},
+	}, completed.Completion)
+}
@@ -0,0 +1,296 @@
+package rpcwatcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/codec"
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	"github.com/emerishq/emeris-utils/store"
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrKeyNotFound is returned when reading a key which isn't in the store.
+var ErrKeyNotFound = errors.New("key not found")
+
+// maxWatchRetries is the number of times a watched Redis transaction is attempted before giving up,
+// when the watched keys keep being modified concurrently.
+const maxWatchRetries = 10
+
+// TicketStore is where watchers record ticket transitions and cache chain data.
+// The ticket transitions behave like the ones of store.Store, which other services read tickets with.
+type TicketStore interface {
+	Exists(key string) bool
+	Get(key string) (store.Ticket, error)
+	SetInTransit(key, destChain, sourceChannel, sendPacketSequence, txHash, chainName string, height int64) error
+	SetIbcReceived(key, txHash, chainName string, height int64) error
+	SetIbcFailed(key, txHash, chainName string, height int64) error
+	SetIbcTimeoutUnlock(key, txHash, chainName string, height int64) error
+	SetIbcAckUnlock(key, txHash, chainName string, height int64) error
+	SetComplete(key string, height int64) error
+	SetFailedWithErr(key, error string, height int64) error
+	SetPoolSwapFees(poolId, offerCoinAmount, offerCoinDenom string) error
+
+	// Value returns the raw value stored at key, or ErrKeyNotFound.
+	Value(key string) ([]byte, error)
+	// SetWithExpiry stores value at key for mul times the default expiry, a zero mul never expires.
+	SetWithExpiry(key string, value interface{}, mul int64) error
+	SetWithExpiryTime(key string, value interface{}, duration time.Duration) error
+	// SetKeepTTL stores value at key, leaving its expiry untouched.
+	SetKeepTTL(key string, value interface{}) error
+	Delete(key string) error
+	// ScanKeys returns about count of the keys starting with prefix, from cursor on, along with the
+	// cursor to continue from, which is zero once all the keys have been returned.
+	ScanKeys(prefix string, cursor uint64, count int64) ([]string, uint64, error)
+
+	// AddMembers adds members to the set stored at key.
+	AddMembers(key string, members ...string) error
+	// Members returns the members of the set stored at key.
+	Members(key string) ([]string, error)
+	// RemoveMembers removes members from the set stored at key.
+	RemoveMembers(key string, members ...string) error
+
+	// Append adds value at the end of the list stored at key, and resets its expiry.
+	Append(key string, value []byte, expiry time.Duration) error
+	// List returns the values of the list stored at key, oldest first.
+	List(key string) ([][]byte, error)
+
+	// AddCoins adds coins to the ones accumulated at key, which expire at expireAt.
+	AddCoins(key string, coins sdktypes.Coins, expireAt time.Time) error
+	// Coins returns the coins accumulated at key.
+	Coins(key string) (sdktypes.Coins, error)
+
+	// AddBlock caches the block_results response data at height for the API server, which reads it
+	// through store.Blocks.
+	AddBlock(data []byte, height int64) error
+	SetLastBlockTime(t time.Time, height int64) error
+
+	// SetBlockResults caches the block_results response bz of chainName at height, and prunes the
+	// results older than the last retention heights.
+	SetBlockResults(chainName string, height int64, bz []byte, retention int64) error
+	// BlockResults returns the cached block_results response of chainName at height, or
+	// store.ErrBlockNotFound.
+	BlockResults(chainName string, height int64) ([]byte, error)
+	// BlockResultsHeights returns the heights whose results are cached for chainName, oldest first.
+	BlockResultsHeights(chainName string) ([]int64, error)
+
+	// Codec returns the codec chain data is encoded with.
+	Codec() codec.Marshaler
+}
+
+// RedisStore is the TicketStore backed by Redis.
+type RedisStore struct {
+	*store.Store
+}
+
+// NewRedisStore returns a TicketStore backed by the Redis instance s is connected to.
+func NewRedisStore(s *store.Store) *RedisStore {
+	return &RedisStore{Store: s}
+}
+
+func (s *RedisStore) Value(key string) ([]byte, error) {
+	res, err := s.Client.Get(context.Background(), key).Bytes()
+	if err == redis.Nil {
+		return nil, ErrKeyNotFound
+	}
+
+	return res, err
+}
+
+func (s *RedisStore) SetKeepTTL(key string, value interface{}) error {
+	return s.Client.Set(context.Background(), key, value, redis.KeepTTL).Err()
+}
+
+func (s *RedisStore) ScanKeys(prefix string, cursor uint64, count int64) ([]string, uint64, error) {
+	return s.Client.Scan(context.Background(), cursor, prefix+"*", count).Result()
+}
+
+func (s *RedisStore) AddMembers(key string, members ...string) error {
+	return s.Client.SAdd(context.Background(), key, stringValues(members)...).Err()
+}
+
+func (s *RedisStore) Members(key string) ([]string, error) {
+	return s.Client.SMembers(context.Background(), key).Result()
+}
+
+func (s *RedisStore) RemoveMembers(key string, members ...string) error {
+	return s.Client.SRem(context.Background(), key, stringValues(members)...).Err()
+}
+
+func stringValues(values []string) []interface{} {
+	ret := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		ret = append(ret, v)
+	}
+
+	return ret
+}
+
+func (s *RedisStore) Append(key string, value []byte, expiry time.Duration) error {
+	ctx := context.Background()
+
+	_, err := s.Client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.RPush(ctx, key, value)
+		p.Expire(ctx, key, expiry)
+		return nil
+	})
+
+	return err
+}
+
+func (s *RedisStore) List(key string) ([][]byte, error) {
+	res, err := s.Client.LRange(context.Background(), key, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([][]byte, 0, len(res))
+	for _, r := range res {
+		values = append(values, []byte(r))
+	}
+
+	return values, nil
+}
+
+// AddCoins accumulates coins in a hash of amounts by denom, watched so that concurrent additions aren't lost.
+func (s *RedisStore) AddCoins(key string, coins sdktypes.Coins, expireAt time.Time) error {
+	ctx := context.Background()
+
+	return s.watchRetry(ctx, func(tx *redis.Tx) error {
+		total, err := hashCoins(ctx, tx, key)
+		if err != nil {
+			return err
+		}
+
+		total = total.Add(coins...)
+
+		_, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			for _, c := range total {
+				p.HSet(ctx, key, c.Denom, c.Amount.String())
+			}
+			p.ExpireAt(ctx, key, expireAt)
+			return nil
+		})
+		return err
+	}, key)
+}
+
+// watchRetry runs fn in a transaction watching keys, and runs it again when they were modified before
+// it committed, up to maxWatchRetries times.
+func (s *RedisStore) watchRetry(ctx context.Context, fn func(*redis.Tx) error, keys ...string) error {
+	for i := 0; i < maxWatchRetries; i++ {
+		err := s.Client.Watch(ctx, fn, keys...)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+
+	return fmt.Errorf("cannot update %v, modified concurrently %d times, %w", keys, maxWatchRetries, redis.TxFailedErr)
+}
+
+func (s *RedisStore) Coins(key string) (sdktypes.Coins, error) {
+	return hashCoins(context.Background(), s.Client, key)
+}
+
+func hashCoins(ctx context.Context, c redis.Cmdable, key string) (sdktypes.Coins, error) {
+	res, err := c.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	coins := sdktypes.NewCoins()
+	for denom, amount := range res {
+		a, ok := sdktypes.NewIntFromString(amount)
+		if !ok {
+			return nil, fmt.Errorf("invalid amount %s for denom %s in %s", amount, denom, key)
+		}
+
+		coins = coins.Add(sdktypes.NewCoin(denom, a))
+	}
+
+	return coins, nil
+}
+
+func (s *RedisStore) AddBlock(data []byte, height int64) error {
+	return store.NewBlocks(s.Store).Add(data, height)
+}
+
+func (s *RedisStore) SetLastBlockTime(t time.Time, height int64) error {
+	return store.NewBlocks(s.Store).SetLastBlockTime(t, height)
+}
+
+// SetBlockResults stores the results along with their height in a sorted set, which the heights to
+// prune are read from.
+func (s *RedisStore) SetBlockResults(chainName string, height int64, bz []byte, retention int64) error {
+	ctx := context.Background()
+	indexKey := fmt.Sprintf(blockResultsIndexKeyFmt, chainName)
+
+	_, err := s.Client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, blockResultsKey(chainName, strconv.FormatInt(height, 10)), bz, 0)
+		p.ZAdd(ctx, indexKey, &redis.Z{Score: float64(height), Member: height})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	pruned, err := s.Client.ZRange(ctx, indexKey, 0, -(retention + 1)).Result()
+	if err != nil {
+		return fmt.Errorf("cannot read cached heights, %w", err)
+	}
+
+	if len(pruned) == 0 {
+		return nil
+	}
+
+	_, err = s.Client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		members := make([]interface{}, 0, len(pruned))
+		for _, h := range pruned {
+			p.Del(ctx, blockResultsKey(chainName, h))
+			members = append(members, h)
+		}
+
+		p.ZRem(ctx, indexKey, members...)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("cannot prune block results, %w", err)
+	}
+
+	return nil
+}
+
+func (s *RedisStore) BlockResults(chainName string, height int64) ([]byte, error) {
+	res, err := s.Client.Get(context.Background(), blockResultsKey(chainName, strconv.FormatInt(height, 10))).Bytes()
+	if err == redis.Nil {
+		return nil, store.ErrBlockNotFound
+	}
+
+	return res, err
+}
+
+func (s *RedisStore) BlockResultsHeights(chainName string) ([]int64, error) {
+	res, err := s.Client.ZRange(context.Background(), fmt.Sprintf(blockResultsIndexKeyFmt, chainName), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	heights := make([]int64, 0, len(res))
+	for _, h := range res {
+		height, err := strconv.ParseInt(h, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cached height %s, %w", h, err)
+		}
+
+		heights = append(heights, height)
+	}
+
+	return heights, nil
+}
+
+func (s *RedisStore) Codec() codec.Marshaler {
+	return s.Cdc
+}
@@ -0,0 +1,275 @@
+package rpcwatcher
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	"github.com/emerishq/emeris-utils/store"
+	"github.com/stretchr/testify/require"
+)
+
+// testTicketStore is a TicketStore tickets can be created in, like the API server does.
+type testTicketStore interface {
+	TicketStore
+	CreateTicket(chain, txHash, owner string) error
+}
+
+func ticketStores() []struct {
+	name  string
+	store testTicketStore
+} {
+	return []struct {
+		name  string
+		store testTicketStore
+	}{
+		{"redis", NewRedisStore(s)},
+		{"memory", NewMemoryStore()},
+	}
+}
+
+func TestTicketStoreTransitions(t *testing.T) {
+	tests := []struct {
+		name       string
+		transition func(t *testing.T, ts TicketStore, key string)
+		expStatus  string
+	}{
+		{
+			"Complete ticket",
+			func(t *testing.T, ts TicketStore, key string) {
+				require.NoError(t, ts.SetComplete(key, 10))
+			},
+			"complete",
+		},
+		{
+			"Failed ticket",
+			func(t *testing.T, ts TicketStore, key string) {
+				require.NoError(t, ts.SetFailedWithErr(key, "out of gas", 10))
+			},
+			"failed",
+		},
+		{
+			"IBC transfer received",
+			func(t *testing.T, ts TicketStore, key string) {
+				require.NoError(t, ts.SetInTransit(key, "akash", "channel-1", "7", "SENDHASH", "cosmos-hub", 10))
+				require.NoError(t, ts.SetIbcReceived(store.GetIBCKey("akash", "channel-1", "7"), "RECVHASH", "akash", 12))
+			},
+			"IBC_receive_success",
+		},
+		{
+			"IBC transfer failed",
+			func(t *testing.T, ts TicketStore, key string) {
+				require.NoError(t, ts.SetInTransit(key, "akash", "channel-1", "7", "SENDHASH", "cosmos-hub", 10))
+				require.NoError(t, ts.SetIbcFailed(store.GetIBCKey("akash", "channel-1", "7"), "RECVHASH", "akash", 12))
+			},
+			"IBC_receive_failed",
+		},
+		{
+			"IBC transfer timed out",
+			func(t *testing.T, ts TicketStore, key string) {
+				require.NoError(t, ts.SetInTransit(key, "akash", "channel-1", "7", "SENDHASH", "cosmos-hub", 10))
+				require.NoError(t, ts.SetIbcTimeoutUnlock(store.GetIBCKey("akash", "channel-1", "7"), "TIMEOUTHASH", "cosmos-hub", 14))
+			},
+			"Tokens_unlocked_timeout",
+		},
+		{
+			"IBC transfer acknowledged with error",
+			func(t *testing.T, ts TicketStore, key string) {
+				require.NoError(t, ts.SetInTransit(key, "akash", "channel-1", "7", "SENDHASH", "cosmos-hub", 10))
+				require.NoError(t, ts.SetIbcAckUnlock(store.GetIBCKey("akash", "channel-1", "7"), "ACKHASH", "cosmos-hub", 14))
+			},
+			"Tokens_unlocked_ack",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer store.ResetTestStore(mr, s)
+
+			// both backends leave tickets in the same state
+			var tickets []store.Ticket
+			for _, b := range ticketStores() {
+				key := store.GetKey("cosmos-hub", "TXHASH")
+				require.NoError(t, b.store.CreateTicket("cosmos-hub", "TXHASH", testOwner), b.name)
+				require.True(t, b.store.Exists("shadow"+key), b.name)
+
+				tt.transition(t, b.store, key)
+
+				ticket, err := b.store.Get(key)
+				require.NoError(t, err, b.name)
+				require.Equal(t, tt.expStatus, ticket.Status, b.name)
+
+				tickets = append(tickets, ticket)
+			}
+
+			require.Equal(t, tickets[0], tickets[1])
+		})
+	}
+
+	for _, b := range ticketStores() {
+		require.Error(t, b.store.SetInTransit("cosmos-hub/MISSING", "akash", "channel-1", "7", "SENDHASH", "cosmos-hub", 10), b.name)
+		require.Error(t, b.store.SetFailedWithErr("cosmos-hub/MISSING", "out of gas", 10), b.name)
+	}
+}
+
+func TestTicketStoreValues(t *testing.T) {
+	for _, b := range ticketStores() {
+		t.Run(b.name, func(t *testing.T) {
+			defer store.ResetTestStore(mr, s)
+			ts := b.store
+
+			_, err := ts.Value("missing")
+			require.ErrorIs(t, err, ErrKeyNotFound)
+
+			require.NoError(t, ts.SetWithExpiry("chain/a", "1", 0))
+			require.NoError(t, ts.SetWithExpiryTime("chain/b", []byte("2"), time.Hour))
+			require.NoError(t, ts.SetWithExpiry("other/c", "3", 1))
+
+			// values keep their expiry when overwritten
+			require.NoError(t, ts.SetKeepTTL("chain/b", "22"))
+			bz, err := ts.Value("chain/b")
+			require.NoError(t, err)
+			require.Equal(t, "22", string(bz))
+
+			var keys []string
+			var cursor uint64
+			for {
+				page, next, err := ts.ScanKeys("chain/", cursor, 1)
+				require.NoError(t, err)
+				keys = append(keys, page...)
+
+				if cursor = next; cursor == 0 {
+					break
+				}
+			}
+			require.ElementsMatch(t, []string{"chain/a", "chain/b"}, keys)
+
+			require.NoError(t, ts.Delete("chain/a"))
+			require.False(t, ts.Exists("chain/a"))
+
+			require.NoError(t, ts.SetWithExpiryTime("expiring", "1", time.Millisecond))
+			time.Sleep(10 * time.Millisecond)
+			if b.name == "redis" {
+				mr.FastForward(10 * time.Millisecond)
+			}
+			require.False(t, ts.Exists("expiring"))
+		})
+	}
+}
+
+func TestTicketStoreLists(t *testing.T) {
+	for _, b := range ticketStores() {
+		t.Run(b.name, func(t *testing.T) {
+			defer store.ResetTestStore(mr, s)
+			ts := b.store
+
+			list, err := ts.List("history/missing")
+			require.NoError(t, err)
+			require.Empty(t, list)
+
+			for _, v := range []string{"a", "b", "c"} {
+				require.NoError(t, ts.Append("history/key", []byte(v), time.Hour))
+			}
+
+			list, err = ts.List("history/key")
+			require.NoError(t, err)
+			require.Equal(t, [][]byte{[]byte("a"), []byte("b"), []byte("c")}, list)
+		})
+	}
+}
+
+func TestTicketStoreSets(t *testing.T) {
+	for _, b := range ticketStores() {
+		t.Run(b.name, func(t *testing.T) {
+			defer store.ResetTestStore(mr, s)
+			ts := b.store
+
+			members, err := ts.Members("set/missing")
+			require.NoError(t, err)
+			require.Empty(t, members)
+
+			require.NoError(t, ts.AddMembers("set/key", "a", "b"))
+			require.NoError(t, ts.AddMembers("set/key", "b", "c"))
+			require.NoError(t, ts.RemoveMembers("set/key", "a"))
+			require.NoError(t, ts.RemoveMembers("set/missing", "a"))
+
+			members, err = ts.Members("set/key")
+			require.NoError(t, err)
+			require.ElementsMatch(t, []string{"b", "c"}, members)
+		})
+	}
+}
+
+func TestTicketStoreCoins(t *testing.T) {
+	for _, b := range ticketStores() {
+		t.Run(b.name, func(t *testing.T) {
+			defer store.ResetTestStore(mr, s)
+			ts := b.store
+
+			coins, err := ts.Coins("fees/missing")
+			require.NoError(t, err)
+			require.True(t, coins.IsZero())
+
+			expireAt := time.Now().Add(time.Hour)
+			require.NoError(t, ts.AddCoins("fees/key", sdktypes.NewCoins(sdktypes.NewInt64Coin("uatom", 10)), expireAt))
+			require.NoError(t, ts.AddCoins("fees/key", sdktypes.NewCoins(sdktypes.NewInt64Coin("uatom", 5), sdktypes.NewInt64Coin("uusd", 3)), expireAt))
+
+			coins, err = ts.Coins("fees/key")
+			require.NoError(t, err)
+			require.Equal(t, sdktypes.NewCoins(sdktypes.NewInt64Coin("uatom", 15), sdktypes.NewInt64Coin("uusd", 3)), coins)
+		})
+	}
+}
+
+func TestRedisStoreConcurrentAddCoins(t *testing.T) {
+	defer store.ResetTestStore(mr, s)
+	rs := NewRedisStore(s)
+
+	// concurrent additions conflict with each other, and are retried rather than lost
+	const additions = 8
+	expireAt := time.Now().Add(time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < additions; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, rs.AddCoins("fees/key", sdktypes.NewCoins(sdktypes.NewInt64Coin("uatom", 1)), expireAt))
+		}()
+	}
+	wg.Wait()
+
+	coins, err := rs.Coins("fees/key")
+	require.NoError(t, err)
+	require.Equal(t, sdktypes.NewCoins(sdktypes.NewInt64Coin("uatom", additions)), coins)
+}
+
+func TestTicketStoreBlockResults(t *testing.T) {
+	for _, b := range ticketStores() {
+		t.Run(b.name, func(t *testing.T) {
+			defer store.ResetTestStore(mr, s)
+			ts := b.store
+
+			for _, h := range []int64{3, 1, 2, 4} {
+				bz, err := json.Marshal(h)
+				require.NoError(t, err)
+				require.NoError(t, ts.SetBlockResults("cosmos-hub", h, bz, 2))
+			}
+
+			heights, err := ts.BlockResultsHeights("cosmos-hub")
+			require.NoError(t, err)
+			require.Equal(t, []int64{3, 4}, heights)
+
+			bz, err := ts.BlockResults("cosmos-hub", 4)
+			require.NoError(t, err)
+			require.Equal(t, "4", string(bz))
+
+			_, err = ts.BlockResults("cosmos-hub", 2)
+			require.ErrorIs(t, err, store.ErrBlockNotFound)
+
+			require.NotNil(t, ts.Codec())
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package rpcwatcher
+
+import (
+	"fmt"
+	"strconv"
+
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	liquiditytypes "github.com/gravity-devs/liquidity/x/liquidity/types"
+)
+
+const swapNotMatchedErrFmt = "swap order %s of pool %s not matched before expiry"
+
+// swapOutcome is the state of a swap order after one of its batches executed.
+type swapOutcome int
+
+const (
+	// swapOpen orders were partially matched and remain in the next batch.
+	swapOpen swapOutcome = iota
+	// swapFilled orders were matched, either in full or partially until expiry.
+	swapFilled
+	// swapNotMatched orders expired or were rejected without being matched.
+	swapNotMatched
+)
+
+// SwapFill is the part of a swap order matched in a single batch.
+type SwapFill struct {
+	BatchIndex          string `json:"batch_index"`
+	SwapPrice           string `json:"swap_price"`
+	TransactedCoin      string `json:"transacted_coin"`
+	ExchangedDemandCoin string `json:"exchanged_demand_coin"`
+	OfferCoinFee        string `json:"offer_coin_fee"`
+	ExchangedCoinFee    string `json:"exchanged_coin_fee"`
+}
+
+// SwapResult describes the execution of a Gravity DEX swap order, as recorded on its ticket.
+type SwapResult struct {
+	PoolID              string     `json:"pool_id"`
+	MsgIndex            string     `json:"msg_index"`
+	OfferCoin           string     `json:"offer_coin"`
+	DemandCoinDenom     string     `json:"demand_coin_denom"`
+	OrderPrice          string     `json:"order_price"`
+	SwapPrice           string     `json:"swap_price,omitempty"`
+	ExchangedOfferCoin  string     `json:"exchanged_offer_coin,omitempty"`
+	ExchangedDemandCoin string     `json:"exchanged_demand_coin,omitempty"`
+	RefundedOfferCoin   string     `json:"refunded_offer_coin,omitempty"`
+	PartiallyFilled     bool       `json:"partially_filled"`
+	Fills               []SwapFill `json:"fills,omitempty"`
+}
+
+// updateSwapResult applies the swap_transacted event attributes attrs, emitted at height, to the
+// execution r of a swap order.
+func updateSwapResult(r SwapResult, attrs map[string]string, height int64) (SwapResult, swapOutcome) {
+	offerDenom := attrs[liquiditytypes.AttributeValueOfferCoinDenom]
+	demandDenom := attrs[liquiditytypes.AttributeValueDemandCoinDenom]
+
+	r.PoolID = attrs[liquiditytypes.AttributeValuePoolId]
+	r.MsgIndex = attrs[liquiditytypes.AttributeValueMsgIndex]
+	r.OfferCoin = attrs[liquiditytypes.AttributeValueOfferCoinAmount] + offerDenom
+	r.DemandCoinDenom = demandDenom
+	r.OrderPrice = attrs[liquiditytypes.AttributeValueOrderPrice]
+	r.ExchangedOfferCoin = attrs[liquiditytypes.AttributeValueExchangedOfferCoinAmount] + offerDenom
+
+	remaining := intAttr(attrs, liquiditytypes.AttributeValueRemainingOfferCoinAmount)
+	expiryHeight, _ := strconv.ParseInt(attrs[liquiditytypes.AttributeValueOrderExpiryHeight], 10, 64)
+
+	if attrs[liquiditytypes.AttributeValueSuccess] == liquiditytypes.Success {
+		r.Fills = append(r.Fills, SwapFill{
+			BatchIndex:          attrs[liquiditytypes.AttributeValueBatchIndex],
+			SwapPrice:           attrs[liquiditytypes.AttributeValueSwapPrice],
+			TransactedCoin:      attrs[liquiditytypes.AttributeValueTransactedCoinAmount] + offerDenom,
+			ExchangedDemandCoin: attrs[liquiditytypes.AttributeValueExchangedDemandCoinAmount] + demandDenom,
+			OfferCoinFee:        attrs[liquiditytypes.AttributeValueOfferCoinFeeAmount] + offerDenom,
+			ExchangedCoinFee:    attrs[liquiditytypes.AttributeValueExchangedCoinFeeAmount] + demandDenom,
+		})
+
+		r.SwapPrice = attrs[liquiditytypes.AttributeValueSwapPrice]
+
+		exchanged := sdktypes.ZeroInt()
+		for _, f := range r.Fills {
+			if c, err := sdktypes.ParseCoinNormalized(f.ExchangedDemandCoin); err == nil {
+				exchanged = exchanged.Add(c.Amount)
+			}
+		}
+		r.ExchangedDemandCoin = exchanged.String() + demandDenom
+
+		if remaining.IsPositive() && expiryHeight > height {
+			return r, swapOpen
+		}
+	}
+
+	// remaining offer coins and their reserved fee are refunded once the order is closed
+	if remaining.IsPositive() {
+		reservedFee := intAttr(attrs, liquiditytypes.AttributeValueReservedOfferCoinFeeAmount)
+		r.RefundedOfferCoin = remaining.Add(reservedFee).String() + offerDenom
+	}
+
+	if len(r.Fills) == 0 {
+		return r, swapNotMatched
+	}
+
+	r.PartiallyFilled = remaining.IsPositive()
+	return r, swapFilled
+}
+
+func intAttr(attrs map[string]string, name string) sdktypes.Int {
+	i, ok := sdktypes.NewIntFromString(attrs[name])
+	if !ok {
+		return sdktypes.ZeroInt()
+	}
+
+	return i
+}
+
+// resolveSwap records the outcome of a batch on the swap ticket stored at key, and resolves it once
+// the order is closed.
+func resolveSwap(w *Watcher, key, batchKey string, attrs map[string]string, events map[string][]string, height int64) {
+	var r SwapResult
+	if _, err := ticketField(w.store, key, "swap", &r); err != nil {
+		w.l.Errorw("cannot read swap result from ticket", "key", key, "error", err)
+		return
+	}
+
+	// blocks can be resolved more than once, the fill of a batch must only be applied once
+	for _, f := range r.Fills {
+		if f.BatchIndex == attrs[liquiditytypes.AttributeValueBatchIndex] {
+			return
+		}
+	}
+
+	r, outcome := updateSwapResult(r, attrs, height)
+
+	if outcome != swapOpen {
+		err := keepTicketFields(w.store, key, func() error {
+			if outcome == swapFilled {
+				return w.store.SetComplete(key, height)
+			}
+
+			return w.store.SetFailedWithErr(key, fmt.Sprintf(swapNotMatchedErrFmt, r.MsgIndex, r.PoolID), height)
+		})
+		if err != nil {
+			w.l.Errorw("cannot resolve swap ticket", "key", key, "error", err)
+			return
+		}
+	}
+
+	if err := setTicketFields(w.store, key, struct {
+		Swap SwapResult `json:"swap"`
+	}{r}); err != nil {
+		w.l.Errorw("cannot set swap result on ticket", "key", key, "error", err)
+	}
+
+	w.recordTransition(key, w.Name, height, events)
+
+	if outcome == swapOpen {
+		return
+	}
+
+	if err := w.store.Delete(batchKey); err != nil {
+		w.l.Errorw("cannot delete batched message", "key", batchKey, "error", err)
+	}
+}
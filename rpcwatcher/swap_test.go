@@ -0,0 +1,268 @@
+package rpcwatcher
+
+import (
+	"testing"
+
+	"github.com/emerishq/emeris-rpcwatcher/rpcwatcher/database"
+	"github.com/emerishq/emeris-utils/store"
+	liquiditytypes "github.com/gravity-devs/liquidity/x/liquidity/types"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+const testOfferDenom = "ibc/2181AAB0218EAC24BC9F86BD1364FBBFA3E6E3FCC25E88E3E68C15DC6E752D86"
+
+// swapTransactedAttrs returns the attributes of a swap_transacted event for the order of the swap
+// transaction fixture, executed in batch.
+func swapTransactedAttrs(batch, success, remaining, expiry string) map[string]string {
+	return map[string]string{
+		"pool_id":                        "5",
+		"batch_index":                    batch,
+		"msg_index":                      "19708",
+		"offer_coin_denom":               testOfferDenom,
+		"offer_coin_amount":              "2000157755",
+		"demand_coin_denom":              "uatom",
+		"order_price":                    "12.429270876237939802",
+		"swap_price":                     "12.4",
+		"transacted_coin_amount":         "1000000000",
+		"remaining_offer_coin_amount":    remaining,
+		"exchanged_offer_coin_amount":    "1000000000",
+		"exchanged_demand_coin_amount":   "80000000",
+		"offer_coin_fee_amount":          "1500000",
+		"exchanged_coin_fee_amount":      "120000",
+		"reserved_offer_coin_fee_amount": "1500236",
+		"order_expiry_height":            expiry,
+		"success":                        success,
+	}
+}
+
+func TestUpdateSwapResult(t *testing.T) {
+	fill := SwapFill{
+		BatchIndex:          "23941",
+		SwapPrice:           "12.4",
+		TransactedCoin:      "1000000000" + testOfferDenom,
+		ExchangedDemandCoin: "80000000uatom",
+		OfferCoinFee:        "1500000" + testOfferDenom,
+		ExchangedCoinFee:    "120000uatom",
+	}
+
+	base := SwapResult{
+		PoolID:             "5",
+		MsgIndex:           "19708",
+		OfferCoin:          "2000157755" + testOfferDenom,
+		DemandCoinDenom:    "uatom",
+		OrderPrice:         "12.429270876237939802",
+		ExchangedOfferCoin: "1000000000" + testOfferDenom,
+	}
+
+	tests := []struct {
+		name       string
+		prev       SwapResult
+		attrs      map[string]string
+		expResult  func() SwapResult
+		expOutcome swapOutcome
+	}{
+		{
+			"filled order",
+			SwapResult{},
+			swapTransactedAttrs("23941", liquiditytypes.Success, "0", "100"),
+			func() SwapResult {
+				r := base
+				r.SwapPrice = "12.4"
+				r.ExchangedDemandCoin = "80000000uatom"
+				r.Fills = []SwapFill{fill}
+				return r
+			},
+			swapFilled,
+		},
+		{
+			"partially matched order remaining open",
+			SwapResult{},
+			swapTransactedAttrs("23941", liquiditytypes.Success, "1000157755", "101"),
+			func() SwapResult {
+				r := base
+				r.SwapPrice = "12.4"
+				r.ExchangedDemandCoin = "80000000uatom"
+				r.Fills = []SwapFill{fill}
+				return r
+			},
+			swapOpen,
+		},
+		{
+			"partially filled order at expiry",
+			SwapResult{Fills: []SwapFill{fill}},
+			swapTransactedAttrs("23942", liquiditytypes.Success, "157755", "100"),
+			func() SwapResult {
+				r := base
+				secondFill := fill
+				secondFill.BatchIndex = "23942"
+				r.SwapPrice = "12.4"
+				r.ExchangedDemandCoin = "160000000uatom"
+				r.RefundedOfferCoin = "1657991" + testOfferDenom
+				r.PartiallyFilled = true
+				r.Fills = []SwapFill{fill, secondFill}
+				return r
+			},
+			swapFilled,
+		},
+		{
+			"order not matched",
+			SwapResult{},
+			swapTransactedAttrs("23941", liquiditytypes.Failure, "2000157755", "100"),
+			func() SwapResult {
+				r := base
+				r.RefundedOfferCoin = "2001657991" + testOfferDenom
+				return r
+			},
+			swapNotMatched,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, outcome := updateSwapResult(tt.prev, tt.attrs, 100)
+			require.Equal(t, tt.expOutcome, outcome)
+			require.Equal(t, tt.expResult(), r)
+		})
+	}
+}
+
+func TestResolveSwap(t *testing.T) {
+	watcherInstance := &Watcher{
+		l:    logger,
+		d:    dbInstance,
+		Name: database.TestChainName,
+	}
+
+	tests := []struct {
+		name      string
+		batches   [][]abci.Event
+		expStatus string
+		expFills  int
+	}{
+		{
+			"swap waiting for batch",
+			nil,
+			batchedStatus,
+			0,
+		},
+		{
+			"swap filled in a single batch",
+			[][]abci.Event{
+				{batchEvent(liquiditytypes.EventTypeSwapTransacted, swapTransactedAttrs("23941", liquiditytypes.Success, "0", "100"))},
+			},
+			"complete",
+			1,
+		},
+		{
+			"swap partially matched",
+			[][]abci.Event{
+				{batchEvent(liquiditytypes.EventTypeSwapTransacted, swapTransactedAttrs("23941", liquiditytypes.Success, "1000157755", "101"))},
+			},
+			batchedStatus,
+			1,
+		},
+		{
+			"swap filled in two batches",
+			[][]abci.Event{
+				{batchEvent(liquiditytypes.EventTypeSwapTransacted, swapTransactedAttrs("23941", liquiditytypes.Success, "1000157755", "101"))},
+				{batchEvent(liquiditytypes.EventTypeSwapTransacted, swapTransactedAttrs("23942", liquiditytypes.Success, "0", "101"))},
+			},
+			"complete",
+			2,
+		},
+		{
+			"swap not matched",
+			[][]abci.Event{
+				{batchEvent(liquiditytypes.EventTypeSwapTransacted, swapTransactedAttrs("23941", liquiditytypes.Failure, "2000157755", "100"))},
+			},
+			"failed",
+			0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := NewMemoryStore()
+			watcherInstance.store = ms
+			data := swapTransactionEvent(t)
+			require.NoError(t, ms.CreateTicket(watcherInstance.Name, swapTxHash, testOwner))
+			key := store.GetKey(database.TestChainName, swapTxHash)
+
+			HandleMessage(watcherInstance, data)
+			for i, events := range tt.batches {
+				resolveBatches(watcherInstance, events, int64(100+i))
+			}
+
+			ticket, err := ms.Get(key)
+			require.NoError(t, err)
+			require.Equal(t, tt.expStatus, ticket.Status)
+
+			var r SwapResult
+			_, err = ticketField(ms, key, "swap", &r)
+			require.NoError(t, err)
+			require.Len(t, r.Fills, tt.expFills)
+		})
+	}
+}
+
+func TestResolveSwapOnce(t *testing.T) {
+	ms := NewMemoryStore()
+	watcherInstance := &Watcher{
+		l:              logger,
+		d:              dbInstance,
+		store:          ms,
+		Name:           database.TestChainName,
+		blockRetention: defaultBlockRetention,
+	}
+
+	// two orders appended to the same batch by two transactions
+	first := swapTransactionEvent(t)
+	eventTx := first.Data.(types.EventDataTx)
+	eventTx.Height = defaultHeight
+	first.Data = eventTx
+
+	second := swapTransactionEvent(t)
+	second.Data = eventTx
+	second.Events = map[string][]string{}
+	for k, v := range first.Events {
+		second.Events[k] = v
+	}
+	second.Events["tx.hash"] = []string{"0" + swapTxHash[1:]}
+	second.Events["swap_within_batch.msg_index"] = []string{"19709"}
+
+	firstAttrs := swapTransactedAttrs("23941", liquiditytypes.Success, "1000157755", "200")
+	secondAttrs := swapTransactedAttrs("23941", liquiditytypes.Success, "1000157755", "200")
+	secondAttrs["msg_index"] = "19709"
+	events := []abci.Event{
+		batchEvent(liquiditytypes.EventTypeSwapTransacted, firstAttrs),
+		batchEvent(liquiditytypes.EventTypeSwapTransacted, secondAttrs),
+	}
+
+	var keys []string
+	for _, data := range []coretypes.ResultEvent{first, second} {
+		txHash := data.Events["tx.hash"][0]
+		require.NoError(t, ms.CreateTicket(watcherInstance.Name, txHash, testOwner))
+		keys = append(keys, store.GetKey(database.TestChainName, txHash))
+	}
+
+	// the block is handled before the transactions, then resolved again by the block processing
+	cacheBlockResults(watcherInstance, blockResultsJSON(t, events...), defaultHeight)
+	HandleMessage(watcherInstance, first)
+	HandleMessage(watcherInstance, second)
+	resolveBatches(watcherInstance, events, defaultHeight)
+
+	for _, key := range keys {
+		ticket, err := ms.Get(key)
+		require.NoError(t, err)
+		require.Equal(t, batchedStatus, ticket.Status)
+
+		var r SwapResult
+		_, err = ticketField(ms, key, "swap", &r)
+		require.NoError(t, err)
+		require.Len(t, r.Fills, 1)
+		require.Equal(t, "80000000uatom", r.ExchangedDemandCoin)
+	}
+}
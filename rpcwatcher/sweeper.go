@@ -0,0 +1,213 @@
+package rpcwatcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+const (
+	pendingStatus = "pending"
+	expiredStatus = "expired"
+
+	pendingSinceKeyFmt   = "pending_since/%s"
+	pendingTicketsKeyFmt = "pending_tickets/%s"
+	ticketExpiredErrFmt  = "transaction not found on chain %s after %d blocks"
+
+	// pending tickets are swept once every sweepBlockInterval blocks
+	sweepBlockInterval = 10
+	// each sweep looks for new pending tickets among the ticket keys, about sweepScanCount at a time and
+	// picking up where the previous sweep stopped, until all keys have been scanned, sweepScanBudget
+	// ticket keys have been read or sweepScanTimeout elapsed
+	sweepScanCount   = 1000
+	sweepScanBudget  = 5000
+	sweepScanTimeout = 500 * time.Millisecond
+	// a pending ticket expires once minPendingBlocks blocks and pendingThreshMul times the chain
+	// valid block threshold elapsed since it's been first seen
+	minPendingBlocks = 20
+	pendingThreshMul = 30
+	// first seen records of tickets that completed are dropped after one day
+	pendingSinceExpiry = 24 * time.Hour
+)
+
+// pendingSince is the first block at which the sweeper observed a pending ticket.
+type pendingSince struct {
+	Height int64     `json:"height"`
+	Time   time.Time `json:"time"`
+}
+
+// HandleExpiredTickets periodically marks as expired the pending tickets of the watched chain whose
+// transaction never landed on chain.
+func HandleExpiredTickets(w *Watcher, data coretypes.ResultEvent) {
+	realData, ok := data.Data.(types.EventDataNewBlock)
+	if !ok {
+		panic("rpc returned block data which is not of expected type")
+	}
+
+	if realData.Block == nil || realData.Block.Height%sweepBlockInterval != 0 {
+		return
+	}
+
+	chain, err := w.d.Chain(w.Name)
+	if err != nil {
+		w.l.Errorw("cannot get chain to sweep pending tickets", "chain_name", w.Name, "error", err)
+		return
+	}
+
+	sweepPendingTickets(w, realData.Block.Height, realData.Block.Time, chain.ValidBlockThresh.Duration())
+}
+
+// indexPendingTickets adds to the pending tickets index of the watched chain the pending tickets among
+// the next ticket keys, so that sweeps only read the tickets of the index.
+func indexPendingTickets(w *Watcher) {
+	deadline := time.Now().Add(sweepScanTimeout)
+	scanned := 0
+
+	for {
+		keys, cursor, err := w.store.ScanKeys(w.Name+"/", w.sweepCursor, sweepScanCount)
+		if err != nil {
+			w.l.Errorw("cannot scan pending tickets", "chain_name", w.Name, "error", err)
+			return
+		}
+
+		w.sweepCursor = cursor
+		scanned += len(keys)
+
+		indexPendingKeys(w, keys)
+
+		if cursor == 0 || scanned >= sweepScanBudget || time.Now().After(deadline) {
+			return
+		}
+	}
+}
+
+// indexPendingKeys adds the pending tickets stored at keys to the pending tickets index of the watched chain.
+func indexPendingKeys(w *Watcher, keys []string) {
+	var pending []string
+	for _, key := range keys {
+		ticket, err := w.store.Get(key)
+		if err != nil {
+			w.l.Errorw("cannot read ticket", "key", key, "error", err)
+			continue
+		}
+
+		if ticket.Status == pendingStatus {
+			pending = append(pending, key)
+		}
+	}
+
+	if len(pending) == 0 {
+		return
+	}
+
+	if err := w.store.AddMembers(fmt.Sprintf(pendingTicketsKeyFmt, w.Name), pending...); err != nil {
+		w.l.Errorw("cannot index pending tickets", "chain_name", w.Name, "error", err)
+	}
+}
+
+func sweepPendingTickets(w *Watcher, height int64, blockTime time.Time, validBlockThresh time.Duration) {
+	indexPendingTickets(w)
+
+	indexKey := fmt.Sprintf(pendingTicketsKeyFmt, w.Name)
+	keys, err := w.store.Members(indexKey)
+	if err != nil {
+		w.l.Errorw("cannot read pending tickets", "chain_name", w.Name, "error", err)
+		return
+	}
+
+	for _, key := range keys {
+		if !w.store.Exists(key) {
+			unindexPendingTicket(w, indexKey, key)
+			continue
+		}
+
+		ticket, err := w.store.Get(key)
+		if err != nil {
+			w.l.Errorw("cannot read ticket", "key", key, "error", err)
+			continue
+		}
+
+		if ticket.Status != pendingStatus {
+			unindexPendingTicket(w, indexKey, key)
+			continue
+		}
+
+		sinceKey := fmt.Sprintf(pendingSinceKeyFmt, key)
+		since, err := readPendingSince(w, sinceKey)
+		if err != nil {
+			w.l.Errorw("cannot read pending ticket first seen block", "key", key, "error", err)
+			continue
+		}
+
+		if since == nil {
+			bz, err := json.Marshal(pendingSince{Height: height, Time: blockTime})
+			if err != nil {
+				w.l.Errorw("cannot marshal pending ticket first seen block", "key", key, "error", err)
+				continue
+			}
+
+			if err := w.store.SetWithExpiryTime(sinceKey, string(bz), pendingSinceExpiry); err != nil {
+				w.l.Errorw("cannot set pending ticket first seen block", "key", key, "error", err)
+			}
+			continue
+		}
+
+		elapsedBlocks := height - since.Height
+		if elapsedBlocks < minPendingBlocks || blockTime.Sub(since.Time) < pendingThreshMul*validBlockThresh {
+			continue
+		}
+
+		w.l.Debugw("expiring pending ticket", "chain_name", w.Name, "key", key, "since_height", since.Height)
+
+		if err := setTicketExpired(w.store, key, fmt.Sprintf(ticketExpiredErrFmt, w.Name, elapsedBlocks), height); err != nil {
+			w.l.Errorw("cannot set ticket as expired", "key", key, "error", err)
+			continue
+		}
+
+		w.recordTransition(key, w.Name, height, nil)
+
+		unindexPendingTicket(w, indexKey, key)
+	}
+}
+
+// setTicketExpired ends the ticket stored at key like a failed one, with the expired status.
+func setTicketExpired(s TicketStore, key, reason string, height int64) error {
+	if err := s.SetFailedWithErr(key, reason, height); err != nil {
+		return err
+	}
+
+	return setTicketFields(s, key, map[string]string{"status": expiredStatus})
+}
+
+// unindexPendingTicket removes the ticket stored at key from the pending tickets index, along with the
+// block it's been first seen at.
+func unindexPendingTicket(w *Watcher, indexKey, key string) {
+	if err := w.store.RemoveMembers(indexKey, key); err != nil {
+		w.l.Errorw("cannot remove ticket from pending tickets", "key", key, "error", err)
+	}
+
+	if err := w.store.Delete(fmt.Sprintf(pendingSinceKeyFmt, key)); err != nil {
+		w.l.Errorw("cannot delete pending ticket first seen block", "key", key, "error", err)
+	}
+}
+
+func readPendingSince(w *Watcher, key string) (*pendingSince, error) {
+	res, err := w.store.Value(key)
+	if err == ErrKeyNotFound {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var since pendingSince
+	if err := json.Unmarshal(res, &since); err != nil {
+		return nil, err
+	}
+
+	return &since, nil
+}
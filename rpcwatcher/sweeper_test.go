@@ -0,0 +1,121 @@
+package rpcwatcher
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/emerishq/emeris-rpcwatcher/rpcwatcher/database"
+	"github.com/emerishq/emeris-utils/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSweepPendingTickets(t *testing.T) {
+	watcherInstance := &Watcher{
+		l:    logger,
+		d:    dbInstance,
+		Name: database.TestChainName,
+	}
+
+	validBlockThresh := 10 * time.Second
+	firstSeen := time.Now()
+
+	tests := []struct {
+		name      string
+		height    int64
+		blockTime time.Time
+		expStatus string
+	}{
+		{
+			"ticket not expired - not enough blocks",
+			defaultHeight + minPendingBlocks - 1,
+			firstSeen.Add(pendingThreshMul * validBlockThresh),
+			"pending",
+		},
+		{
+			"ticket not expired - not enough time",
+			defaultHeight + minPendingBlocks,
+			firstSeen.Add(validBlockThresh),
+			"pending",
+		},
+		{
+			"ticket expired",
+			defaultHeight + minPendingBlocks,
+			firstSeen.Add(pendingThreshMul * validBlockThresh),
+			"expired",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := NewMemoryStore()
+			watcherInstance.store = ms
+			watcherInstance.sweepCursor = 0
+			require.NoError(t, ms.CreateTicket(watcherInstance.Name, nonIBCTransferTxHash, testOwner))
+			key := store.GetKey(database.TestChainName, nonIBCTransferTxHash)
+			indexKey := fmt.Sprintf(pendingTicketsKeyFmt, database.TestChainName)
+
+			// first sweep only records when the ticket has been seen
+			sweepPendingTickets(watcherInstance, defaultHeight, firstSeen, validBlockThresh)
+			ticket, err := ms.Get(key)
+			require.NoError(t, err)
+			require.Equal(t, "pending", ticket.Status)
+			members, err := ms.Members(indexKey)
+			require.NoError(t, err)
+			require.Equal(t, []string{key}, members)
+
+			sweepPendingTickets(watcherInstance, tt.height, tt.blockTime, validBlockThresh)
+			ticket, err = ms.Get(key)
+			require.NoError(t, err)
+			require.Equal(t, tt.expStatus, ticket.Status)
+			if tt.expStatus == "expired" {
+				require.Equal(t, fmt.Sprintf(ticketExpiredErrFmt, database.TestChainName, minPendingBlocks), ticket.Error)
+				members, err = ms.Members(indexKey)
+				require.NoError(t, err)
+				require.Empty(t, members)
+			} else {
+				require.True(t, ms.Exists(indexKey))
+			}
+		})
+	}
+}
+
+func TestSweepIndexedTickets(t *testing.T) {
+	ms := NewMemoryStore()
+	watcherInstance := &Watcher{
+		l:     logger,
+		d:     dbInstance,
+		store: ms,
+		Name:  database.TestChainName,
+	}
+	indexKey := fmt.Sprintf(pendingTicketsKeyFmt, database.TestChainName)
+
+	// tickets are picked up across scan windows, until the scan budget of a sweep is spent
+	for i := 0; i <= sweepScanBudget; i++ {
+		require.NoError(t, ms.CreateTicket(database.TestChainName, fmt.Sprintf("TXHASH%05d", i), testOwner))
+	}
+
+	sweepPendingTickets(watcherInstance, defaultHeight, time.Now(), time.Second)
+	members, err := ms.Members(indexKey)
+	require.NoError(t, err)
+	require.Len(t, members, sweepScanBudget)
+	require.Contains(t, members, store.GetKey(database.TestChainName, fmt.Sprintf("TXHASH%05d", sweepScanCount)))
+	require.NotZero(t, watcherInstance.sweepCursor)
+
+	sweepPendingTickets(watcherInstance, defaultHeight+sweepBlockInterval, time.Now(), time.Second)
+	members, err = ms.Members(indexKey)
+	require.NoError(t, err)
+	require.Len(t, members, sweepScanBudget+1)
+	require.Zero(t, watcherInstance.sweepCursor)
+
+	// tickets which aren't pending anymore leave the index
+	key := store.GetKey(database.TestChainName, "TXHASH00000")
+	require.NoError(t, ms.SetComplete(key, defaultHeight+sweepBlockInterval))
+
+	sweepPendingTickets(watcherInstance, defaultHeight+2*sweepBlockInterval, time.Now(), time.Second)
+	members, err = ms.Members(indexKey)
+	require.NoError(t, err)
+	require.Len(t, members, sweepScanBudget)
+	require.NotContains(t, members, key)
+	require.False(t, ms.Exists(fmt.Sprintf(pendingSinceKeyFmt, key)))
+}
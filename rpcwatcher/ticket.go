@@ -0,0 +1,212 @@
+package rpcwatcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	ticketHistoryKeyFmt = "history/%s"
+
+	// ticket history outlives tickets, so that disputes can be investigated after completion
+	ticketHistoryExpiry = 30 * 24 * time.Hour
+)
+
+// setTicketError records reason as the error of the ticket stored at key, leaving its status, expiry
+// and any other field untouched.
+func setTicketError(s TicketStore, key, reason string) error {
+	return setTicketFields(s, key, struct {
+		Error string `json:"error"`
+	}{reason})
+}
+
+// setIBCTicketError records reason as the error of the ticket the IBC packet stored at ibcKey
+// originates from.
+func setIBCTicketError(s TicketStore, ibcKey, reason string) error {
+	ibcTicket, err := s.Get(ibcKey)
+	if err != nil {
+		return err
+	}
+
+	return setTicketError(s, ibcTicket.Info, reason)
+}
+
+// TicketEvent is an entry of the audit trail of a ticket, recorded each time a handler applies a
+// transition to it.
+type TicketEvent struct {
+	Status     string              `json:"status"`
+	Error      string              `json:"error,omitempty"`
+	Chain      string              `json:"chain"`
+	TxHash     string              `json:"tx_hash,omitempty"`
+	Height     int64               `json:"height"`
+	Timestamp  time.Time           `json:"timestamp"`
+	Attributes map[string][]string `json:"attributes,omitempty"`
+}
+
+// TicketHistory returns the transitions applied to the ticket stored at key, oldest first.
+func TicketHistory(s TicketStore, key string) ([]TicketEvent, error) {
+	res, err := s.List(fmt.Sprintf(ticketHistoryKeyFmt, key))
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]TicketEvent, 0, len(res))
+	for _, r := range res {
+		var e TicketEvent
+		if err := json.Unmarshal(r, &e); err != nil {
+			return nil, fmt.Errorf("cannot unmarshal ticket event, %w", err)
+		}
+
+		history = append(history, e)
+	}
+
+	return history, nil
+}
+
+func appendTicketHistory(s TicketStore, key string, e TicketEvent) error {
+	bz, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return s.Append(fmt.Sprintf(ticketHistoryKeyFmt, key), bz, ticketHistoryExpiry)
+}
+
+// recordTransition appends the current state of the ticket stored at key to its history, along with
+// the attributes of the event which triggered the transition.
+func (w *Watcher) recordTransition(key, chainName string, height int64, events map[string][]string) {
+	ticket, err := w.store.Get(key)
+	if err != nil {
+		w.l.Errorw("cannot read ticket to record history", "key", key, "error", err)
+		return
+	}
+
+	e := TicketEvent{
+		Status:     ticket.Status,
+		Error:      ticket.Error,
+		Chain:      chainName,
+		Height:     height,
+		Timestamp:  time.Now().UTC(),
+		Attributes: events,
+	}
+
+	if txHash, ok := events["tx.hash"]; ok && len(txHash) > 0 {
+		e.TxHash = txHash[0]
+	}
+
+	if err := appendTicketHistory(w.store, key, e); err != nil {
+		w.l.Errorw("cannot append ticket history", "key", key, "error", err)
+	}
+}
+
+// recordIBCTransition records a transition of the ticket the IBC packet stored at ibcKey originates from.
+func (w *Watcher) recordIBCTransition(ibcKey, chainName string, height int64, events map[string][]string) {
+	ibcTicket, err := w.store.Get(ibcKey)
+	if err != nil {
+		w.l.Errorw("cannot read ibc ticket to record history", "key", ibcKey, "error", err)
+		return
+	}
+
+	w.recordTransition(ibcTicket.Info, chainName, height, events)
+}
+
+// setTicketFields merges the JSON fields of v into the ticket stored at key, leaving its expiry untouched.
+// Fields not known to store.Ticket are kept as long as the ticket isn't overwritten by a later transition.
+func setTicketFields(s TicketStore, key string, v interface{}) error {
+	res, err := s.Value(key)
+	if err != nil {
+		return err
+	}
+
+	ticket := map[string]json.RawMessage{}
+	if err := json.Unmarshal(res, &ticket); err != nil {
+		return fmt.Errorf("cannot unmarshal ticket, %w", err)
+	}
+
+	bz, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(bz, &fields); err != nil {
+		return fmt.Errorf("cannot unmarshal ticket fields, %w", err)
+	}
+
+	for k, f := range fields {
+		ticket[k] = f
+	}
+
+	bz, err = json.Marshal(ticket)
+	if err != nil {
+		return err
+	}
+
+	return s.SetKeepTTL(key, bz)
+}
+
+// keepTicketFields runs transition, which overwrites the ticket stored at key, then restores the fields
+// previously recorded on the ticket which aren't part of store.Ticket.
+func keepTicketFields(s TicketStore, key string, transition func() error) error {
+	res, err := s.Value(key)
+	if err != nil {
+		return err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(res, &fields); err != nil {
+		return fmt.Errorf("cannot unmarshal ticket, %w", err)
+	}
+
+	for _, f := range []string{"owner", "info", "height", "status", "tx_hashes", "error"} {
+		delete(fields, f)
+	}
+
+	if err := transition(); err != nil {
+		return err
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return setTicketFields(s, key, fields)
+}
+
+// keepIBCTicketFields runs transition, which overwrites the ticket the IBC packet stored at ibcKey
+// originates from, keeping the fields recorded on it which aren't part of store.Ticket.
+// The transition still runs if the originating ticket has already expired.
+func keepIBCTicketFields(s TicketStore, ibcKey string, transition func() error) error {
+	ibcTicket, err := s.Get(ibcKey)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.Value(ibcTicket.Info); err == ErrKeyNotFound {
+		return transition()
+	}
+
+	return keepTicketFields(s, ibcTicket.Info, transition)
+}
+
+// ticketField decodes the field name recorded on the ticket stored at key into v, and returns false if
+// the ticket has no such field.
+func ticketField(s TicketStore, key, name string, v interface{}) (bool, error) {
+	res, err := s.Value(key)
+	if err != nil {
+		return false, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(res, &fields); err != nil {
+		return false, fmt.Errorf("cannot unmarshal ticket, %w", err)
+	}
+
+	f, ok := fields[name]
+	if !ok {
+		return false, nil
+	}
+
+	return true, json.Unmarshal(f, v)
+}
@@ -0,0 +1,73 @@
+package rpcwatcher
+
+import (
+	"testing"
+
+	"github.com/emerishq/emeris-rpcwatcher/rpcwatcher/database"
+	"github.com/emerishq/emeris-utils/store"
+	"github.com/stretchr/testify/require"
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+)
+
+func TestTicketHistory(t *testing.T) {
+	watcherInstance := &Watcher{
+		l:    logger,
+		d:    dbInstance,
+		Name: database.TestChainName,
+	}
+
+	tests := []struct {
+		name      string
+		data      coretypes.ResultEvent
+		txHash    func() string
+		eventType string
+		expStatus []string
+	}{
+		{
+			"History of non IBC transaction",
+			nonIBCTransferEvent(t, true),
+			func() string { return nonIBCTransferTxHash },
+			"",
+			[]string{"complete"},
+		},
+		{
+			"History of failed non IBC transaction",
+			nonIBCTransferEvent(t, false),
+			func() string { return nonIBCTransferTxHash },
+			"",
+			[]string{"failed"},
+		},
+		{
+			"History of IBC receive packet transaction",
+			ibcReceivePacketEvent(t, true),
+			func() string { return ibcReceiveTxHash },
+			"recv_packet",
+			[]string{"IBC_receive_success"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := NewMemoryStore()
+			watcherInstance.store = ms
+			txHash := tt.txHash()
+			require.NoError(t, ms.CreateTicket(watcherInstance.Name, txHash, testOwner))
+			key := store.GetKey(database.TestChainName, txHash)
+			if tt.eventType != "" {
+				checkAndSetInTransit(t, tt.data, watcherInstance, txHash, tt.eventType, key)
+			}
+
+			HandleMessage(watcherInstance, tt.data)
+
+			history, err := TicketHistory(ms, key)
+			require.NoError(t, err)
+			require.Len(t, history, len(tt.expStatus))
+			for i, e := range history {
+				require.Equal(t, tt.expStatus[i], e.Status)
+				require.Equal(t, watcherInstance.Name, e.Chain)
+				require.Equal(t, tt.data.Events, e.Attributes)
+				require.Equal(t, tt.data.Events["tx.hash"][0], e.TxHash)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package rpcwatcher
+
+import (
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+// TxCost holds what a transaction cost its signer, as recorded on its ticket.
+type TxCost struct {
+	GasUsed   int64  `json:"gas_used"`
+	GasWanted int64  `json:"gas_wanted"`
+	Fee       string `json:"fee,omitempty"`
+	Memo      string `json:"memo,omitempty"`
+}
+
+// txCost returns the gas consumed by eventTx along with the fee and memo decoded from its bytes.
+// Gas values are always returned, even if the transaction cannot be decoded.
+func txCost(eventTx types.EventDataTx) (TxCost, error) {
+	c := TxCost{
+		GasUsed:   eventTx.Result.GasUsed,
+		GasWanted: eventTx.Result.GasWanted,
+	}
+
+	body, authInfo, err := decodeRawTx(eventTx.Tx)
+	if err != nil {
+		return c, err
+	}
+
+	if authInfo.Fee != nil {
+		c.Fee = authInfo.Fee.Amount.String()
+	}
+
+	c.Memo = body.Memo
+
+	return c, nil
+}
+
+// recordTxInfo stores the cost of the transaction carried by data on the ticket stored at key, along
+// with the authz and fee grant parties if any.
+func (w *Watcher) recordTxInfo(key string, data coretypes.ResultEvent) {
+	eventTx, ok := data.Data.(types.EventDataTx)
+	if !ok {
+		return
+	}
+
+	c, err := txCost(eventTx)
+	if err != nil {
+		w.l.Errorw("cannot read tx fee and memo", "key", key, "error", err)
+	}
+
+	info := struct {
+		TxCost
+		Authorization *TxAuthorization `json:"authorization,omitempty"`
+	}{TxCost: c}
+
+	if wrapped, err := unwrapTx(w.store.Codec(), eventTx.Tx); err == nil && !wrapped.Authorization.Empty() {
+		info.Authorization = &wrapped.Authorization
+	}
+
+	if err := setTicketFields(w.store, key, info); err != nil {
+		w.l.Errorw("cannot set tx info on ticket", "key", key, "error", err)
+	}
+}
@@ -0,0 +1,120 @@
+package rpcwatcher
+
+import (
+	"encoding/json"
+	"testing"
+
+	sdktypes "github.com/cosmos/cosmos-sdk/types"
+	gaia "github.com/cosmos/gaia/v5/app"
+	"github.com/emerishq/emeris-rpcwatcher/rpcwatcher/database"
+	"github.com/emerishq/emeris-utils/store"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/tendermint/abci/types"
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestTxCost(t *testing.T) {
+	txConfig := gaia.MakeEncodingConfig().TxConfig
+	txBuilder := txConfig.NewTxBuilder()
+	txBuilder.SetFeeAmount(sdktypes.NewCoins(sdktypes.NewInt64Coin("uatom", 5000)))
+	txBuilder.SetGasLimit(200000)
+	txBuilder.SetMemo("test memo")
+	txBytes, err := txConfig.TxEncoder()(txBuilder.GetTx())
+	require.NoError(t, err)
+
+	result := abci.ResponseDeliverTx{
+		GasUsed:   123456,
+		GasWanted: 200000,
+	}
+
+	tests := []struct {
+		name    string
+		eventTx types.EventDataTx
+		expCost TxCost
+		expErr  bool
+	}{
+		{
+			"empty tx",
+			types.EventDataTx{TxResult: abci.TxResult{Result: result}},
+			TxCost{
+				GasUsed:   123456,
+				GasWanted: 200000,
+			},
+			true,
+		},
+		{
+			"invalid tx",
+			types.EventDataTx{TxResult: abci.TxResult{Tx: []byte("invalid"), Result: result}},
+			TxCost{
+				GasUsed:   123456,
+				GasWanted: 200000,
+			},
+			true,
+		},
+		{
+			"valid tx",
+			types.EventDataTx{TxResult: abci.TxResult{Tx: txBytes, Result: result}},
+			TxCost{
+				GasUsed:   123456,
+				GasWanted: 200000,
+				Fee:       "5000uatom",
+				Memo:      "test memo",
+			},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := txCost(tt.eventTx)
+			if tt.expErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.expCost, c)
+		})
+	}
+}
+
+func TestIBCTxCost(t *testing.T) {
+	ms := NewMemoryStore()
+	watcherInstance := &Watcher{
+		l:     logger,
+		d:     dbInstance,
+		store: ms,
+		Name:  database.TestChainName,
+	}
+
+	const gasUsed = 91204
+
+	sendEvent := ibcTransferEvent(t)
+	sendEvent.Data = types.EventDataTx{TxResult: abci.TxResult{Result: abci.ResponseDeliverTx{GasUsed: gasUsed}}}
+	key := store.GetKey(database.TestChainName, ibcTransferTxHash)
+	require.NoError(t, ms.CreateTicket(watcherInstance.Name, ibcTransferTxHash, testOwner))
+
+	requireGasUsed := func(status string) {
+		res, err := ms.Value(key)
+		require.NoError(t, err)
+
+		var fields struct {
+			Status  string `json:"status"`
+			GasUsed int64  `json:"gas_used"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(res), &fields))
+		require.Equal(t, status, fields.Status)
+		require.Equal(t, int64(gasUsed), fields.GasUsed)
+	}
+
+	HandleIBCSenderEvent(watcherInstance, sendEvent, watcherInstance.Name, ibcTransferTxHash, key, defaultHeight)
+	requireGasUsed("transit")
+
+	ackEvent := coretypes.ResultEvent{Events: map[string][]string{
+		"acknowledge_packet.packet_src_channel": sendEvent.Events["send_packet.packet_src_channel"],
+		"acknowledge_packet.packet_sequence":    sendEvent.Events["send_packet.packet_sequence"],
+		"fungible_token_packet.error":           {"\u0001"},
+	}}
+	HandleIBCAckPacket(watcherInstance, ackEvent, watcherInstance.Name, ibcAckTxHash, defaultHeight+1)
+	requireGasUsed("Tokens_unlocked_ack")
+}
@@ -0,0 +1,179 @@
+package rpcwatcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	transfertypes "github.com/cosmos/cosmos-sdk/x/ibc/applications/transfer/types"
+	"google.golang.org/grpc"
+)
+
+const (
+	verifiedTraceKeyFmt = "verified_trace/%s/%s"
+
+	// trace verifications are cached for one hour (12 * defaultExpiry)
+	verifiedTraceExpiryMul = 12
+
+	// unverified traces are cached briefly, so that a channel or denom verified in CNS afterwards is
+	// picked up soon
+	unverifiedTraceExpiry = time.Minute
+)
+
+// TraceHop is a channel an IBC denom went through, walking from the chain holding it towards its source.
+type TraceHop struct {
+	Channel          string `json:"channel"`
+	Port             string `json:"port"`
+	ChainName        string `json:"chain_name"`
+	CounterpartyName string `json:"counterparty_name"`
+}
+
+// VerifiedTrace is the result of the verification of the trace of an IBC denom.
+type VerifiedTrace struct {
+	IbcDenom  string     `json:"ibc_denom"`
+	BaseDenom string     `json:"base_denom"`
+	Verified  bool       `json:"verified"`
+	Path      string     `json:"path"`
+	Trace     []TraceHop `json:"trace"`
+}
+
+// traceHops splits an IBC denom trace path into its port and channel pairs.
+func traceHops(path string) ([][2]string, error) {
+	parts := strings.Split(path, "/")
+	if path == "" || len(parts)%2 != 0 {
+		return nil, fmt.Errorf("invalid trace path %s", path)
+	}
+
+	hops := make([][2]string, 0, len(parts)/2)
+	for i := 0; i < len(parts); i += 2 {
+		hops = append(hops, [2]string{parts[i], parts[i+1]})
+	}
+
+	return hops, nil
+}
+
+// verifyTrace verifies the trace of the IBC denom with the given hash on the watched chain: every hop
+// must go through the CNS primary channel of the respective chain, and the base denom must be verified
+// on the chain the trace ends on.
+// Results are cached in the store, unverified ones for a shorter time.
+func (w *Watcher) verifyTrace(hash string) (VerifiedTrace, error) {
+	cacheKey := fmt.Sprintf(verifiedTraceKeyFmt, w.Name, hash)
+	cached, err := w.store.Value(cacheKey)
+	switch {
+	case err == nil:
+		var vt VerifiedTrace
+		if err := json.Unmarshal(cached, &vt); err == nil {
+			return vt, nil
+		}
+
+		w.l.Errorw("cannot unmarshal cached trace verification", "key", cacheKey, "error", err)
+	case err != ErrKeyNotFound:
+		w.l.Errorw("cannot read trace verification from cache", "key", cacheKey, "error", err)
+	}
+
+	trace, err := w.queryDenomTrace(hash)
+	if err != nil {
+		return VerifiedTrace{}, err
+	}
+
+	vt, err := w.verifyDenomTrace(trace)
+	if err != nil {
+		return VerifiedTrace{}, err
+	}
+
+	bz, err := json.Marshal(vt)
+	if err != nil {
+		return VerifiedTrace{}, err
+	}
+
+	if vt.Verified {
+		err = w.store.SetWithExpiry(cacheKey, string(bz), verifiedTraceExpiryMul)
+	} else {
+		err = w.store.SetWithExpiryTime(cacheKey, string(bz), unverifiedTraceExpiry)
+	}
+
+	if err != nil {
+		w.l.Errorw("cannot cache trace verification", "key", cacheKey, "error", err)
+	}
+
+	return vt, nil
+}
+
+// queryDenomTrace queries the ibc-transfer module of the watched chain for the trace of the IBC denom
+// with the given hash.
+func (w *Watcher) queryDenomTrace(hash string) (transfertypes.DenomTrace, error) {
+	grpcConn, err := grpc.Dial(
+		w.grpcEndpoint,
+		grpc.WithInsecure(),
+	)
+	if err != nil {
+		return transfertypes.DenomTrace{}, fmt.Errorf("cannot create gRPC client, %w", err)
+	}
+
+	defer func() {
+		if err := grpcConn.Close(); err != nil {
+			w.l.Errorw("cannot close gRPC client", "error", err, "chain_name", w.Name)
+		}
+	}()
+
+	res, err := transfertypes.NewQueryClient(grpcConn).DenomTrace(context.Background(), &transfertypes.QueryDenomTraceRequest{
+		Hash: hash,
+	})
+	if err != nil {
+		return transfertypes.DenomTrace{}, fmt.Errorf("cannot query denom trace %s, %w", hash, err)
+	}
+
+	if res.DenomTrace == nil {
+		return transfertypes.DenomTrace{}, fmt.Errorf("no denom trace found for %s", hash)
+	}
+
+	return *res.DenomTrace, nil
+}
+
+// verifyDenomTrace walks trace from the watched chain, looking up the counterparty of each hop in CNS.
+func (w *Watcher) verifyDenomTrace(trace transfertypes.DenomTrace) (VerifiedTrace, error) {
+	vt := VerifiedTrace{
+		IbcDenom:  trace.IBCDenom(),
+		BaseDenom: trace.BaseDenom,
+		Path:      trace.Path,
+	}
+
+	hops, err := traceHops(trace.Path)
+	if err != nil {
+		return VerifiedTrace{}, err
+	}
+
+	chainName := w.Name
+	for _, hop := range hops {
+		c, err := w.d.GetCounterParty(chainName, hop[1])
+		if err != nil {
+			w.l.Debugw("trace hop is not a primary channel", "chain_name", chainName, "port", hop[0], "channel", hop[1], "error", err)
+			return vt, nil
+		}
+
+		vt.Trace = append(vt.Trace, TraceHop{
+			Channel:          hop[1],
+			Port:             hop[0],
+			ChainName:        chainName,
+			CounterpartyName: c[0].Counterparty,
+		})
+
+		chainName = c[0].Counterparty
+	}
+
+	source, err := w.d.Chain(chainName)
+	if err != nil {
+		return VerifiedTrace{}, err
+	}
+
+	for _, d := range source.Denoms {
+		if d.Name == trace.BaseDenom {
+			vt.Verified = d.Verified
+			break
+		}
+	}
+
+	return vt, nil
+}
@@ -0,0 +1,239 @@
+package rpcwatcher
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	transfertypes "github.com/cosmos/cosmos-sdk/x/ibc/applications/transfer/types"
+	"github.com/emerishq/emeris-rpcwatcher/rpcwatcher/database"
+	"github.com/emerishq/emeris-utils/store"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type testTransferQueryServer struct {
+	*transfertypes.UnimplementedQueryServer
+	traces map[string]transfertypes.DenomTrace
+}
+
+func (q testTransferQueryServer) DenomTrace(_ context.Context, req *transfertypes.QueryDenomTraceRequest) (*transfertypes.QueryDenomTraceResponse, error) {
+	trace, ok := q.traces[req.Hash]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "denomination trace not found")
+	}
+
+	return &transfertypes.QueryDenomTraceResponse{DenomTrace: &trace}, nil
+}
+
+func TestTraceHops(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		expHops [][2]string
+		expErr  bool
+	}{
+		{
+			"single hop",
+			"transfer/channel-0",
+			[][2]string{{"transfer", "channel-0"}},
+			false,
+		},
+		{
+			"two hops",
+			"transfer/channel-0/transfer/channel-7",
+			[][2]string{{"transfer", "channel-0"}, {"transfer", "channel-7"}},
+			false,
+		},
+		{
+			"empty path",
+			"",
+			nil,
+			true,
+		},
+		{
+			"channel missing",
+			"transfer/channel-0/transfer",
+			nil,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hops, err := traceHops(tt.path)
+			if tt.expErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expHops, hops)
+		})
+	}
+}
+
+func TestVerifyTrace(t *testing.T) {
+	defer store.ResetTestStore(mr, s)
+
+	// cosmos-hub is its own counterparty on channel-0 in the test CNS data, and lists uatom as verified
+	atom := transfertypes.ParseDenomTrace("transfer/channel-0/uatom")
+	unknown := transfertypes.ParseDenomTrace("transfer/channel-0/uunknown")
+	notPrimary := transfertypes.ParseDenomTrace("transfer/channel-42/uatom")
+	multiHop := transfertypes.ParseDenomTrace("transfer/channel-0/transfer/channel-0/uatom")
+	multiHopNotPrimary := transfertypes.ParseDenomTrace("transfer/channel-0/transfer/channel-42/uatom")
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := grpc.NewServer()
+	transfertypes.RegisterQueryServer(srv, testTransferQueryServer{
+		traces: map[string]transfertypes.DenomTrace{
+			atom.Hash().String():               atom,
+			unknown.Hash().String():            unknown,
+			notPrimary.Hash().String():         notPrimary,
+			multiHop.Hash().String():           multiHop,
+			multiHopNotPrimary.Hash().String(): multiHopNotPrimary,
+		},
+	})
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	defer srv.Stop()
+
+	watcherInstance := &Watcher{
+		l:            logger,
+		d:            dbInstance,
+		store:        NewRedisStore(s),
+		Name:         database.TestChainName,
+		grpcEndpoint: lis.Addr().String(),
+	}
+
+	tests := []struct {
+		name     string
+		hash     string
+		expTrace VerifiedTrace
+		expErr   bool
+	}{
+		{
+			"verified trace",
+			atom.Hash().String(),
+			VerifiedTrace{
+				IbcDenom:  atom.IBCDenom(),
+				BaseDenom: "uatom",
+				Verified:  true,
+				Path:      "transfer/channel-0",
+				Trace: []TraceHop{
+					{
+						Channel:          "channel-0",
+						Port:             "transfer",
+						ChainName:        database.TestChainName,
+						CounterpartyName: database.TestChainName,
+					},
+				},
+			},
+			false,
+		},
+		{
+			"base denom not verified on source chain",
+			unknown.Hash().String(),
+			VerifiedTrace{
+				IbcDenom:  unknown.IBCDenom(),
+				BaseDenom: "uunknown",
+				Path:      "transfer/channel-0",
+				Trace: []TraceHop{
+					{
+						Channel:          "channel-0",
+						Port:             "transfer",
+						ChainName:        database.TestChainName,
+						CounterpartyName: database.TestChainName,
+					},
+				},
+			},
+			false,
+		},
+		{
+			"hop not through a primary channel",
+			notPrimary.Hash().String(),
+			VerifiedTrace{
+				IbcDenom:  notPrimary.IBCDenom(),
+				BaseDenom: "uatom",
+				Path:      "transfer/channel-42",
+			},
+			false,
+		},
+		{
+			"verified multi hop trace",
+			multiHop.Hash().String(),
+			VerifiedTrace{
+				IbcDenom:  multiHop.IBCDenom(),
+				BaseDenom: "uatom",
+				Verified:  true,
+				Path:      "transfer/channel-0/transfer/channel-0",
+				Trace: []TraceHop{
+					{
+						Channel:          "channel-0",
+						Port:             "transfer",
+						ChainName:        database.TestChainName,
+						CounterpartyName: database.TestChainName,
+					},
+					{
+						Channel:          "channel-0",
+						Port:             "transfer",
+						ChainName:        database.TestChainName,
+						CounterpartyName: database.TestChainName,
+					},
+				},
+			},
+			false,
+		},
+		{
+			"second hop not through a primary channel",
+			multiHopNotPrimary.Hash().String(),
+			VerifiedTrace{
+				IbcDenom:  multiHopNotPrimary.IBCDenom(),
+				BaseDenom: "uatom",
+				Path:      "transfer/channel-0/transfer/channel-42",
+				Trace: []TraceHop{
+					{
+						Channel:          "channel-0",
+						Port:             "transfer",
+						ChainName:        database.TestChainName,
+						CounterpartyName: database.TestChainName,
+					},
+				},
+			},
+			false,
+		},
+		{
+			"unknown denom trace",
+			"B5CB286F69D48B2C4F6F8D8CF59011C40590DCF8A91617A5FBA9FF0A7B21307F",
+			VerifiedTrace{},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vt, err := watcherInstance.verifyTrace(tt.hash)
+			if tt.expErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expTrace, vt)
+
+			key := fmt.Sprintf(verifiedTraceKeyFmt, database.TestChainName, tt.hash)
+			require.True(t, s.Exists(key))
+			if vt.Verified {
+				require.Greater(t, mr.TTL(key), unverifiedTraceExpiry)
+			} else {
+				require.Equal(t, unverifiedTraceExpiry, mr.TTL(key))
+			}
+		})
+	}
+}
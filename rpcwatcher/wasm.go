@@ -0,0 +1,219 @@
+package rpcwatcher
+
+import (
+	"strings"
+
+	"github.com/emerishq/emeris-utils/store"
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+const (
+	// wasmPortPrefix prefixes the IBC ports bound by CosmWasm contracts, such as CW20-ICS20 bridges.
+	wasmPortPrefix = "wasm."
+
+	wasmContractAddressAttr = "_contract_address"
+
+	wasmTypeExecution    = "contract_execution"
+	wasmTypeCW20Transfer = "cw20_transfer"
+	wasmTypeCW20Bridge   = "cw20_ics20_transfer"
+)
+
+// cw20TransferActions holds the wasm.action attribute values emitted by CW20 token transfers.
+var cw20TransferActions = map[string]bool{
+	"transfer":      true,
+	"transfer_from": true,
+	"send":          true,
+	"send_from":     true,
+}
+
+// WasmExecution describes a CosmWasm contract execution, as recorded on its ticket.
+type WasmExecution struct {
+	Type       string              `json:"type"`
+	Contracts  []string            `json:"contracts"`
+	Attributes map[string][]string `json:"attributes,omitempty"`
+}
+
+// isICS20Port returns true if port carries ICS-20 fungible token packets, either through the transfer
+// module or a CW20-ICS20 contract.
+func isICS20Port(port string) bool {
+	return port == transferPort || strings.HasPrefix(port, wasmPortPrefix)
+}
+
+// wasmPort returns the source port of the IBC packet event stored at eventKey in data, if bound by a
+// CosmWasm contract.
+func wasmPort(data coretypes.ResultEvent, eventKey string) (string, bool) {
+	port, ok := data.Events[eventKey]
+	if !ok || len(port) == 0 || !strings.HasPrefix(port[0], wasmPortPrefix) {
+		return "", false
+	}
+
+	return port[0], true
+}
+
+// wasmExecution reads the contracts executed by data along with the attributes of their wasm and
+// custom wasm-* events.
+func wasmExecution(data coretypes.ResultEvent) WasmExecution {
+	e := WasmExecution{
+		Type:       wasmTypeExecution,
+		Attributes: map[string][]string{},
+	}
+
+	contracts, ok := data.Events["execute."+wasmContractAddressAttr]
+	if !ok {
+		contracts = data.Events["wasm."+wasmContractAddressAttr]
+	}
+
+	seen := map[string]bool{}
+	for _, c := range contracts {
+		if !seen[c] {
+			seen[c] = true
+			e.Contracts = append(e.Contracts, c)
+		}
+	}
+
+	for k, v := range data.Events {
+		if !strings.HasPrefix(k, "wasm") || strings.HasSuffix(k, "."+wasmContractAddressAttr) {
+			continue
+		}
+
+		e.Attributes[k] = v
+	}
+
+	for _, action := range data.Events["wasm.action"] {
+		if cw20TransferActions[action] {
+			e.Type = wasmTypeCW20Transfer
+			break
+		}
+	}
+
+	if _, ok := wasmPort(data, "send_packet.packet_src_port"); ok {
+		e.Type = wasmTypeCW20Bridge
+	}
+
+	return e
+}
+
+// HandleWasmMessage handles the transactions of chains running x/wasm, tracking contract executions
+// and CW20-ICS20 packets. Any other transaction is handled by HandleMessage.
+func HandleWasmMessage(w *Watcher, data coretypes.ResultEvent) {
+	_, executeEventPresent := data.Events["execute."+wasmContractAddressAttr]
+	_, wasmEventPresent := data.Events["wasm."+wasmContractAddressAttr]
+	txHashSlice := data.Events["tx.hash"]
+
+	eventTx, ok := data.Data.(types.EventDataTx)
+	if !ok || eventTx.Result.Code != 0 || len(txHashSlice) == 0 || (!executeEventPresent && !wasmEventPresent) {
+		HandleMessage(w, data)
+		return
+	}
+
+	txHash := txHashSlice[0]
+	chainName := w.Name
+	height := eventTx.Height
+	key := store.GetKey(chainName, txHash)
+
+	if port, ok := wasmPort(data, "acknowledge_packet.packet_src_port"); ok {
+		HandleWasmAckPacket(w, data, chainName, port, txHash, height)
+		return
+	}
+
+	if port, ok := wasmPort(data, "timeout_packet.packet_src_port"); ok {
+		HandleWasmTimeoutPacket(w, data, chainName, port, txHash, height)
+		return
+	}
+
+	if !executeEventPresent {
+		HandleMessage(w, data)
+		return
+	}
+
+	HandleWasmExecution(w, data, chainName, txHash, key, height)
+}
+
+// HandleWasmExecution completes the ticket of a contract execution, recording the contracts executed
+// and their attributes. CW20-ICS20 transfers are set in transit instead, until their packet is received.
+func HandleWasmExecution(w *Watcher, data coretypes.ResultEvent, chainName, txHash, key string, height int64) {
+	w.l.Debugw("called HandleWasmExecution")
+	if !w.store.Exists(key) {
+		w.l.Debugw("bypassing key, event not sourced from us", "chain_name", w.Name, "key", key, "event", "wasm_execute")
+		return
+	}
+
+	e := wasmExecution(data)
+
+	if e.Type == wasmTypeCW20Bridge {
+		sendPacketSourcePort, _ := wasmPort(data, "send_packet.packet_src_port")
+
+		sendPacketSourceChannel, ok := data.Events["send_packet.packet_src_channel"]
+		if !ok {
+			w.l.Errorf("send_packet.packet_src_channel not found")
+			return
+		}
+
+		sendPacketSequence, ok := data.Events["send_packet.packet_sequence"]
+		if !ok {
+			w.l.Errorf("send_packet.packet_sequence not found")
+			return
+		}
+
+		counterparty, err := w.counterparty(chainName, sendPacketSourcePort, sendPacketSourceChannel[0])
+		if err != nil {
+			w.l.Errorw("unable to fetch counterparty chain", "error", err)
+			return
+		}
+
+		if err := w.store.SetInTransit(key, counterparty, sendPacketSourceChannel[0], sendPacketSequence[0],
+			txHash, chainName, height); err != nil {
+			w.l.Errorw("unable to set status as in transit for key", "key", key, "error", err)
+			return
+		}
+	} else if err := w.store.SetComplete(key, height); err != nil {
+		w.l.Errorw("cannot set complete", "chain name", chainName, "error", err)
+		return
+	}
+
+	w.recordTxInfo(key, data)
+
+	if err := setTicketFields(w.store, key, struct {
+		Wasm WasmExecution `json:"wasm"`
+	}{e}); err != nil {
+		w.l.Errorw("cannot set wasm execution on ticket", "key", key, "error", err)
+	}
+
+	w.recordTransition(key, chainName, height, data.Events)
+}
+
+// wasmApp returns the ibcApp of the CW20-ICS20 contract bound to port.
+func wasmApp(port string) ibcApp {
+	return ibcApp{
+		name:     "wasm",
+		port:     port,
+		isPort:   isICS20Port,
+		ackError: wasmAckError,
+	}
+}
+
+// wasmAckError reads the acknowledgement outcome reported by a CW20-ICS20 contract in its wasm event.
+func wasmAckError(events map[string][]string) (string, bool) {
+	success, ok := events["wasm.success"]
+	if !ok || (len(success) > 0 && success[0] == "true") {
+		return "", false
+	}
+
+	if packetErr, ok := events["wasm.error"]; ok && len(packetErr) > 0 {
+		return packetErr[0], true
+	}
+
+	return "", true
+}
+
+// HandleWasmAckPacket unlocks the tokens of a CW20-ICS20 transfer whose packet has been acknowledged
+// with an error by the receiving chain.
+func HandleWasmAckPacket(w *Watcher, data coretypes.ResultEvent, chainName, port, txHash string, height int64) {
+	handleIBCAck(w, wasmApp(port), data, chainName, txHash, height)
+}
+
+// HandleWasmTimeoutPacket unlocks the tokens of a CW20-ICS20 transfer whose packet timed out.
+func HandleWasmTimeoutPacket(w *Watcher, data coretypes.ResultEvent, chainName, port, txHash string, height int64) {
+	handleIBCTimeout(w, wasmApp(port), data, chainName, txHash, height)
+}
@@ -0,0 +1,256 @@
+package rpcwatcher
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/emerishq/emeris-rpcwatcher/rpcwatcher/database"
+	"github.com/emerishq/emeris-utils/store"
+	"github.com/stretchr/testify/require"
+	coretypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+const (
+	testContract      = "juno1qsrercqegvs4ye0yqg93knv73ye5dc3prqwd6jcdcuj8ggp6w0us66deup"
+	testICS20Contract = "juno1v4887y83d6g28puzvt8cl0f3cdhd3y6y9mpysnsp3k8krdm7l6jqgm0rkn"
+)
+
+// wasmExecutionEvent returns a transaction event executing testContract with the given module events.
+func wasmExecutionEvent(t *testing.T, events map[string][]string) coretypes.ResultEvent {
+	event := nonIBCTransferEvent(t, true)
+	event.Events["message.action"] = []string{"/cosmwasm.wasm.v1.MsgExecuteContract"}
+	event.Events["execute._contract_address"] = []string{testContract}
+	event.Events["wasm._contract_address"] = []string{testContract}
+	for k, v := range events {
+		event.Events[k] = v
+	}
+
+	return event
+}
+
+func TestWasmExecution(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         coretypes.ResultEvent
+		expExecution WasmExecution
+	}{
+		{
+			"contract execution",
+			wasmExecutionEvent(t, map[string][]string{
+				"wasm.action":      {"mint"},
+				"wasm-minted.size": {"42"},
+			}),
+			WasmExecution{
+				Type:      wasmTypeExecution,
+				Contracts: []string{testContract},
+				Attributes: map[string][]string{
+					"wasm.action":      {"mint"},
+					"wasm-minted.size": {"42"},
+				},
+			},
+		},
+		{
+			"cw20 transfer",
+			wasmExecutionEvent(t, map[string][]string{
+				"wasm.action": {"transfer"},
+				"wasm.amount": {"1000"},
+			}),
+			WasmExecution{
+				Type:      wasmTypeCW20Transfer,
+				Contracts: []string{testContract},
+				Attributes: map[string][]string{
+					"wasm.action": {"transfer"},
+					"wasm.amount": {"1000"},
+				},
+			},
+		},
+		{
+			"cw20 ics20 transfer",
+			wasmExecutionEvent(t, map[string][]string{
+				"execute._contract_address":   {testContract, testICS20Contract},
+				"wasm.action":                 {"send", "transfer"},
+				"send_packet.packet_src_port": {wasmPortPrefix + testICS20Contract},
+			}),
+			WasmExecution{
+				Type:      wasmTypeCW20Bridge,
+				Contracts: []string{testContract, testICS20Contract},
+				Attributes: map[string][]string{
+					"wasm.action": {"send", "transfer"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expExecution, wasmExecution(tt.data))
+		})
+	}
+}
+
+func TestHandleWasmMessage(t *testing.T) {
+	watcherInstance := &Watcher{
+		l:    logger,
+		d:    dbInstance,
+		Name: database.TestChainName,
+	}
+
+	tests := []struct {
+		name      string
+		data      coretypes.ResultEvent
+		expStatus string
+		expType   string
+	}{
+		{
+			"Handle contract execution",
+			wasmExecutionEvent(t, map[string][]string{"wasm.action": {"mint"}}),
+			"complete",
+			wasmTypeExecution,
+		},
+		{
+			"Handle cw20 transfer",
+			wasmExecutionEvent(t, map[string][]string{"wasm.action": {"transfer"}}),
+			"complete",
+			wasmTypeCW20Transfer,
+		},
+		{
+			"Handle cw20 ics20 transfer",
+			wasmExecutionEvent(t, map[string][]string{
+				"wasm.action":                    {"send"},
+				"send_packet.packet_src_port":    {wasmPortPrefix + testICS20Contract},
+				"send_packet.packet_src_channel": {defaultChannel},
+				"send_packet.packet_sequence":    {defaultPktSeq},
+			}),
+			"transit",
+			wasmTypeCW20Bridge,
+		},
+		{
+			"Handle non wasm transaction",
+			nonIBCTransferEvent(t, true),
+			"complete",
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := NewMemoryStore()
+			watcherInstance.store = ms
+			require.NoError(t, ms.CreateTicket(watcherInstance.Name, nonIBCTransferTxHash, testOwner))
+			key := store.GetKey(database.TestChainName, nonIBCTransferTxHash)
+
+			HandleWasmMessage(watcherInstance, tt.data)
+
+			ticket, err := ms.Get(key)
+			require.NoError(t, err)
+			require.Equal(t, tt.expStatus, ticket.Status)
+
+			res, err := ms.Value(key)
+			require.NoError(t, err)
+
+			var fields struct {
+				Wasm *WasmExecution `json:"wasm"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(res), &fields))
+			if tt.expType == "" {
+				require.Nil(t, fields.Wasm)
+				return
+			}
+
+			require.NotNil(t, fields.Wasm)
+			require.Equal(t, tt.expType, fields.Wasm.Type)
+		})
+	}
+}
+
+func TestHandleWasmUnlock(t *testing.T) {
+	port := wasmPortPrefix + testICS20Contract
+
+	tests := []struct {
+		name      string
+		events    map[string][]string
+		expStatus string
+		expError  string
+	}{
+		{
+			"Handle cw20 ics20 successful acknowledgement",
+			map[string][]string{
+				"acknowledge_packet.packet_src_port":    {port},
+				"acknowledge_packet.packet_src_channel": {defaultChannel},
+				"acknowledge_packet.packet_sequence":    {defaultPktSeq},
+				"wasm.success":                          {"true"},
+			},
+			"transit",
+			"",
+		},
+		{
+			"Handle cw20 ics20 error acknowledgement",
+			map[string][]string{
+				"acknowledge_packet.packet_src_port":    {port},
+				"acknowledge_packet.packet_src_channel": {defaultChannel},
+				"acknowledge_packet.packet_sequence":    {defaultPktSeq},
+				"wasm.success":                          {"false"},
+				"wasm.error":                            {defaultAckError},
+			},
+			"Tokens_unlocked_ack",
+			defaultAckError,
+		},
+		{
+			"Handle cw20 ics20 timeout",
+			map[string][]string{
+				"timeout_packet.packet_src_port":    {port},
+				"timeout_packet.packet_src_channel": {defaultChannel},
+				"timeout_packet.packet_sequence":    {defaultPktSeq},
+			},
+			"Tokens_unlocked_timeout",
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := NewMemoryStore()
+			watcherInstance := &Watcher{
+				l:     logger,
+				d:     dbInstance,
+				store: ms,
+				Name:  database.TestChainName,
+			}
+
+			send := wasmExecutionEvent(t, map[string][]string{
+				"wasm.action":                    {"send"},
+				"send_packet.packet_src_port":    {port},
+				"send_packet.packet_src_channel": {defaultChannel},
+				"send_packet.packet_sequence":    {defaultPktSeq},
+			})
+			eventTx := send.Data.(types.EventDataTx)
+			eventTx.Result.GasUsed = depositGasUsed
+			send.Data = eventTx
+
+			require.NoError(t, ms.CreateTicket(watcherInstance.Name, nonIBCTransferTxHash, testOwner))
+			key := store.GetKey(database.TestChainName, nonIBCTransferTxHash)
+
+			HandleWasmMessage(watcherInstance, send)
+			HandleWasmMessage(watcherInstance, wasmExecutionEvent(t, tt.events))
+
+			ticket, err := ms.Get(key)
+			require.NoError(t, err)
+			require.Equal(t, tt.expStatus, ticket.Status)
+			require.Equal(t, tt.expError, ticket.Error)
+
+			// the cost and the execution recorded when sending are kept by the unlock
+			res, err := ms.Value(key)
+			require.NoError(t, err)
+
+			var fields struct {
+				Wasm    *WasmExecution `json:"wasm"`
+				GasUsed int64          `json:"gas_used"`
+			}
+			require.NoError(t, json.Unmarshal(res, &fields))
+			require.NotNil(t, fields.Wasm)
+			require.Equal(t, wasmTypeCW20Bridge, fields.Wasm.Type)
+			require.Equal(t, int64(depositGasUsed), fields.GasUsed)
+		})
+	}
+}
@@ -1,22 +1,17 @@
 package rpcwatcher
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"net/url"
-	"strconv"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
-	"google.golang.org/grpc"
 
-	"github.com/emerishq/emeris-rpcwatcher/rpcwatcher/database"
 	"github.com/emerishq/emeris-utils/store"
 
-	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
 	liquiditytypes "github.com/gravity-devs/liquidity/x/liquidity/types"
 	tmjson "github.com/tendermint/tendermint/libs/json"
 	coretypes "github.com/tendermint/tendermint/rpc/core/types"
@@ -34,7 +29,6 @@ const (
 	defaultWatchdogTimeout  = 20 * time.Second
 	defaultReconnectionTime = 15 * time.Second
 	defaultResubscribeSleep = 500 * time.Millisecond
-	defaultTimeGap          = 750 * time.Millisecond
 )
 
 var (
@@ -46,6 +40,10 @@ var (
 		},
 		EventsBlock: {
 			HandleNewBlock,
+			HandleBlockResults,
+			HandleChainSnapshot,
+			HandleDenomSync,
+			HandleExpiredTickets,
 		},
 	}
 	CosmosHubMappings = map[string][]DataHandler{
@@ -55,6 +53,33 @@ var (
 		EventsBlock: {
 			HandleNewBlock,
 			HandleCosmosHubBlock,
+			HandleChainSnapshot,
+			HandleDenomSync,
+			HandleExpiredTickets,
+		},
+	}
+	OsmosisMappings = map[string][]DataHandler{
+		EventsTx: {
+			HandleOsmosisMessage,
+		},
+		EventsBlock: {
+			HandleNewBlock,
+			HandleBlockResults,
+			HandleChainSnapshot,
+			HandleDenomSync,
+			HandleExpiredTickets,
+		},
+	}
+	WasmMappings = map[string][]DataHandler{
+		EventsTx: {
+			HandleWasmMessage,
+		},
+		EventsBlock: {
+			HandleNewBlock,
+			HandleBlockResults,
+			HandleChainSnapshot,
+			HandleDenomSync,
+			HandleExpiredTickets,
 		},
 	}
 )
@@ -67,23 +92,9 @@ type WsResponse struct {
 
 type Events map[string][]string
 
-type VerifyTraceResponse struct {
-	VerifyTrace struct {
-		IbcDenom  string `json:"ibc_denom"`
-		BaseDenom string `json:"base_denom"`
-		Verified  bool   `json:"verified"`
-		Path      string `json:"path"`
-		Trace     []struct {
-			Channel          string `json:"channel"`
-			Port             string `json:"port"`
-			ChainName        string `json:"chain_name"`
-			CounterpartyName string `json:"counterparty_name"`
-		} `json:"trace"`
-	} `json:"verify_trace"`
-}
-
 type Ack struct {
-	Result string `json:"result"`
+	Result string `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
 }
 
 type Watcher struct {
@@ -92,14 +103,24 @@ type Watcher struct {
 	ErrorChannel chan error
 
 	eventTypeMappings map[string][]DataHandler
-	apiUrl            string
 	client            *client.WSClient
-	d                 *database.Instance
+	d                 ChainRegistry
 	l                 *zap.SugaredLogger
-	store             *store.Store
+	store             TicketStore
 	runContext        context.Context
 	endpoint          string
 	grpcEndpoint      string
+	lcdEndpoint       string
+	rpcClient         *http.Client
+	blockRetention    int64
+	snapshotQueries   []string
+	snapshotInterval  int64
+	denomSyncInterval int64
+	denomSyncDryRun   bool
+	denomSyncing      int32
+	snapshotting      int32
+	sweepCursor       uint64
+	ticketsMu         *sync.Mutex
 	subs              []string
 	stopReadChannel   chan struct{}
 	stopErrorChannel  chan struct{}
@@ -109,9 +130,9 @@ type Watcher struct {
 func NewWatcher(
 	endpoint, chainName string,
 	logger *zap.SugaredLogger,
-	apiUrl, grpcEndpoint string,
-	db *database.Instance,
-	s *store.Store,
+	grpcEndpoint string,
+	db ChainRegistry,
+	s TicketStore,
 	subscriptions []string,
 	eventTypeMappings map[string][]DataHandler,
 ) (*Watcher, error) {
@@ -148,7 +169,6 @@ func NewWatcher(
 	wd := newWatchdog(defaultWatchdogTimeout)
 
 	w := &Watcher{
-		apiUrl:            apiUrl,
 		d:                 db,
 		client:            ws,
 		l:                 logger,
@@ -156,6 +176,9 @@ func NewWatcher(
 		Name:              chainName,
 		endpoint:          endpoint,
 		grpcEndpoint:      grpcEndpoint,
+		rpcClient:         newRPCClient(),
+		blockRetention:    defaultBlockRetention,
+		snapshotInterval:  defaultSnapshotInterval,
 		subs:              subscriptions,
 		eventTypeMappings: eventTypeMappings,
 		stopReadChannel:   make(chan struct{}),
@@ -163,9 +186,10 @@ func NewWatcher(
 		stopErrorChannel:  make(chan struct{}),
 		ErrorChannel:      make(chan error),
 		watchdog:          wd,
+		ticketsMu:         &sync.Mutex{},
 	}
 
-	w.l.Debugw("creating rpcwatcher with config", "apiurl", apiUrl)
+	w.l.Debugw("creating rpcwatcher with config", "endpoint", endpoint, "grpc_endpoint", grpcEndpoint)
 
 	for _, sub := range subscriptions {
 		if err := w.client.Subscribe(context.Background(), sub); err != nil {
@@ -266,13 +290,18 @@ func resubscribe(w *Watcher) {
 		count++
 		w.l.Debugw("this is count", "count", count)
 
-		ww, err := NewWatcher(w.endpoint, w.Name, w.l, w.apiUrl, w.grpcEndpoint, w.d, w.store, w.subs, w.eventTypeMappings)
+		ww, err := NewWatcher(w.endpoint, w.Name, w.l, w.grpcEndpoint, w.d, w.store, w.subs, w.eventTypeMappings)
 		if err != nil {
 			w.l.Errorw("cannot resubscribe to chain", "name", w.Name, "endpoint", w.endpoint, "error", err)
 			continue
 		}
 
 		ww.runContext = w.runContext
+		ww.ticketsMu = w.ticketsMu
+		ww.blockRetention = w.blockRetention
+		ww.SetSnapshots(w.snapshotQueries, w.snapshotInterval)
+		ww.SetLCDEndpoint(w.lcdEndpoint)
+		ww.SetDenomSync(w.denomSyncInterval, w.denomSyncDryRun)
 		w = ww
 
 		Start(w, w.runContext)
@@ -307,9 +336,13 @@ func (w *Watcher) startChain(ctx context.Context) {
 					continue
 				}
 
+				// tickets are also updated by the background processing of blocks, which must not
+				// interleave with the handlers
+				w.ticketsMu.Lock()
 				for _, handler := range handlers {
 					handler(w, data)
 				}
+				w.ticketsMu.Unlock()
 			}
 		}
 
@@ -324,6 +357,10 @@ func HandleMessage(w *Watcher, data coretypes.ResultEvent) {
 	_, IBCReceivePacketEventPresent := data.Events["recv_packet.packet_sequence"]
 	_, IBCTimeoutEventPresent := data.Events["timeout.refund_receiver"]
 	_, SwapTransactionEventPresent := data.Events["swap_within_batch.pool_id"]
+	_, DepositTransactionEventPresent := data.Events["deposit_within_batch.pool_id"]
+	_, WithdrawTransactionEventPresent := data.Events["withdraw_within_batch.pool_id"]
+	NFTTransferEventPresent := isNFTTransfer(data)
+	txType, TypedTransactionPresent := typedAction(w.store.Codec(), data)
 
 	if len(txHashSlice) == 0 {
 		return
@@ -336,7 +373,8 @@ func HandleMessage(w *Watcher, data coretypes.ResultEvent) {
 	key := store.GetKey(chainName, txHash)
 
 	w.l.Debugw("got message to handle", "chain name", chainName, "key", key, "is create lp", createPoolEventPresent, "is ibc", IBCSenderEventPresent, "is ibc recv", IBCReceivePacketEventPresent,
-		"is ibc ack", IBCAckEventPresent, "is ibc timeout", IBCTimeoutEventPresent)
+		"is ibc ack", IBCAckEventPresent, "is ibc timeout", IBCTimeoutEventPresent, "is nft transfer", NFTTransferEventPresent,
+		"tx type", txType)
 
 	w.l.Debugw("is simple ibc transfer"+
 		"", "is it", exists && !createPoolEventPresent && !IBCSenderEventPresent && !IBCReceivePacketEventPresent && w.store.Exists(key))
@@ -349,19 +387,40 @@ func HandleMessage(w *Watcher, data coretypes.ResultEvent) {
 		if err := w.store.SetFailedWithErr(key, logStr, height); err != nil {
 			w.l.Errorw("cannot set failed with err", "chain name", chainName, "error", err,
 				"txHash", txHash, "code", eventTx.Result.Code)
+			return
 		}
 
+		w.recordTxInfo(key, data)
+		w.recordTransition(key, chainName, height, data.Events)
 		return
 	}
 	// Handle case where a simple non-IBC transfer is being used.
 	if exists && !createPoolEventPresent && !IBCSenderEventPresent && !IBCReceivePacketEventPresent &&
-		!IBCAckEventPresent && !IBCTimeoutEventPresent && !SwapTransactionEventPresent && w.store.Exists(key) {
+		!IBCAckEventPresent && !IBCTimeoutEventPresent && !SwapTransactionEventPresent && !DepositTransactionEventPresent &&
+		!WithdrawTransactionEventPresent && !NFTTransferEventPresent && !TypedTransactionPresent && w.store.Exists(key) {
 		if err := w.store.SetComplete(key, height); err != nil {
 			w.l.Errorw("cannot set complete", "chain name", chainName, "error", err)
+			return
 		}
+
+		w.recordTxInfo(key, data)
+		w.recordTransition(key, chainName, height, data.Events)
 		return
 	}
 
+	// Handle staking, distribution and governance transactions. When they also carry IBC, NFT or DEX
+	// messages, those are handled first and the typed completion is recorded along.
+	if TypedTransactionPresent {
+		if !createPoolEventPresent && !IBCSenderEventPresent && !IBCReceivePacketEventPresent && !IBCAckEventPresent &&
+			!IBCTimeoutEventPresent && !SwapTransactionEventPresent && !DepositTransactionEventPresent &&
+			!WithdrawTransactionEventPresent && !NFTTransferEventPresent {
+			HandleTypedTransaction(w, data, chainName, key, txType, height)
+			return
+		}
+
+		defer w.recordTypedCompletion(key, data, txType)
+	}
+
 	// Handle case where an LP is being created on the Cosmos Hub
 	if createPoolEventPresent && chainName == "cosmos-hub" {
 		w.l.Debugw("is create lp", "is it", createPoolEventPresent)
@@ -374,6 +433,22 @@ func HandleMessage(w *Watcher, data coretypes.ResultEvent) {
 		return
 	}
 
+	if DepositTransactionEventPresent && w.Name == "cosmos-hub" {
+		HandleBatchedTransaction(w, data, chainName, key, liquiditytypes.EventTypeDepositWithinBatch, height)
+		return
+	}
+
+	if WithdrawTransactionEventPresent && w.Name == "cosmos-hub" {
+		HandleBatchedTransaction(w, data, chainName, key, liquiditytypes.EventTypeWithdrawWithinBatch, height)
+		return
+	}
+
+	// Handle ICS-721 NFT transfers, in any stage of the packet lifecycle.
+	if NFTTransferEventPresent {
+		HandleNFTTransfer(w, data, chainName, txHash, key, height)
+		return
+	}
+
 	// Handle case where an IBC transfer is sent from the origin chain.
 	if IBCSenderEventPresent {
 		HandleIBCSenderEvent(w, data, chainName, txHash, key, height)
@@ -382,6 +457,7 @@ func HandleMessage(w *Watcher, data coretypes.ResultEvent) {
 
 	// Handle case where IBC transfer is received by the receiving chain.
 	if IBCReceivePacketEventPresent {
+		discoverIBCDenoms(w, data, chainName)
 		HandleIBCReceivePacket(w, data, chainName, txHash, height)
 		return
 	}
@@ -404,124 +480,41 @@ func HandleCosmosHubBlock(w *Watcher, data coretypes.ResultEvent) {
 		panic("rpc returned data which is not of expected type")
 	}
 
-	time.Sleep(defaultTimeGap) // to handle the time gap between block production and event broadcast
 	newHeight := realData.Block.Header.Height
+	blockTime := realData.Block.Time
 
-	u := w.endpoint
-
-	ru, err := url.Parse(u)
-	if err != nil {
-		w.l.Errorw("cannot parse url", "url_string", u, "error", err)
-		return
-	}
-
-	vals := url.Values{}
-	vals.Set("height", strconv.FormatInt(newHeight, 10))
-
-	w.l.Debugw("asking for block", "height", newHeight)
-
-	ru.Path = "block_results"
-	ru.RawQuery = vals.Encode()
-
-	res := bytes.Buffer{}
-
-	resp, err := http.Get(ru.String())
-	if err != nil {
-		w.l.Errorw("cannot query node for block data", "error", err, "height", newHeight)
-		return
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		w.l.Errorw("endpoint returned non-200 code", "code", resp.StatusCode, "height", newHeight)
-		return
-	}
-
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-
-	read, err := res.ReadFrom(resp.Body)
-	if err != nil {
-		w.l.Errorw("cannot read block data resp body into buffer", "height", newHeight, "error", err)
-		return
-	}
-
-	if read == 0 {
-		w.l.Errorw("read zero bytes from response body", "height", newHeight)
-	}
+	// block results are only available once the node indexed them, await them without stalling the
+	// handling of transactions
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultBlockResultsDeadline)
+		defer cancel()
 
-	bs := store.NewBlocks(w.store)
-	err = bs.Add(res.Bytes(), newHeight)
-	if err != nil {
-		w.l.Errorw("cannot set block to cache", "error", err, "height", newHeight)
-		return
-	}
-
-	// creating a grpc ClientConn to perform RPCs
-	grpcConn, err := grpc.Dial(
-		w.grpcEndpoint,
-		grpc.WithInsecure(),
-	)
-	if err != nil {
-		w.l.Errorw("cannot create gRPC client", "error", err, "chain_name", w.Name, "address", w.grpcEndpoint)
-		return
-	}
-
-	defer func() {
-		if err := grpcConn.Close(); err != nil {
-			w.l.Errorw("cannot close gRPC client", "error", err, "chain_name", w.Name)
+		results, err := awaitBlockResults(ctx, w, newHeight)
+		if err != nil {
+			w.l.Errorw("cannot query node for block data", "error", err, "height", newHeight)
+			return
 		}
-	}()
-
-	liquidityQuery := liquiditytypes.NewQueryClient(grpcConn)
-	poolsRes, err := liquidityQuery.LiquidityPools(context.Background(), &liquiditytypes.QueryLiquidityPoolsRequest{})
-	if err != nil {
-		w.l.Errorw("cannot get liquidity pools in blocks", "error", err, "height", newHeight)
-	}
-
-	bz, err := w.store.Cdc.MarshalJSON(poolsRes)
-	if err != nil {
-		w.l.Errorw("cannot marshal liquidity pools", "error", err, "height", newHeight)
-	}
-
-	// caching pools info
-	err = w.store.SetWithExpiry("pools", string(bz), 0)
-	if err != nil {
-		w.l.Errorw("cannot set liquidity pools", "error", err, "height", newHeight)
-	}
 
-	paramsRes, err := liquidityQuery.Params(context.Background(), &liquiditytypes.QueryParamsRequest{})
-	if err != nil {
-		w.l.Errorw("cannot get liquidity params", "error", err, "height", newHeight)
-	}
-
-	bz, err = w.store.Cdc.MarshalJSON(paramsRes)
-	if err != nil {
-		w.l.Errorw("cannot marshal liquidity params", "error", err, "height", newHeight)
-	}
+		err = w.store.AddBlock(results, newHeight)
+		if err != nil {
+			w.l.Errorw("cannot set block to cache", "error", err, "height", newHeight)
+			return
+		}
 
-	// caching liquidity params
-	err = w.store.SetWithExpiry("params", string(bz), 0)
-	if err != nil {
-		w.l.Errorw("cannot set liquidity params", "error", err, "height", newHeight)
-	}
+		cacheBlockResults(w, results, newHeight)
 
-	supplyQuery := banktypes.NewQueryClient(grpcConn)
-	supplyRes, err := supplyQuery.TotalSupply(context.Background(), &banktypes.QueryTotalSupplyRequest{})
-	if err != nil {
-		w.l.Errorw("cannot get total supply", "error", err, "height", newHeight)
-	}
+		events, err := endBlockEvents(results)
+		if err != nil {
+			w.l.Errorw("cannot read end block events", "error", err, "height", newHeight)
+			return
+		}
 
-	bz, err = w.store.Cdc.MarshalJSON(supplyRes)
-	if err != nil {
-		w.l.Errorw("cannot marshal total supply", "error", err, "height", newHeight)
-	}
+		w.ticketsMu.Lock()
+		resolveBatches(w, events, newHeight)
+		w.ticketsMu.Unlock()
 
-	// caching total supply
-	err = w.store.SetWithExpiry("supply", string(bz), 0)
-	if err != nil {
-		w.l.Errorw("cannot set total supply", "error", err, "height", newHeight)
-	}
+		recordPoolSwapFees(w, events, blockTime)
+	}()
 }
 
 func HandleNewBlock(w *Watcher, data coretypes.ResultEvent) {
@@ -538,9 +531,7 @@ func HandleNewBlock(w *Watcher, data coretypes.ResultEvent) {
 		w.l.Warnw("weird block received on rpc, it was empty while it shouldn't", "chain_name", w.Name)
 	}
 
-	b := store.NewBlocks(w.store)
-
-	if err := b.SetLastBlockTime(realData.Block.Time, realData.Block.Height); err != nil {
+	if err := w.store.SetLastBlockTime(realData.Block.Time, realData.Block.Height); err != nil {
 		w.l.Errorw("cannot write last block time to store", "chain_name", w.Name, "error", err)
 		return
 	}
@@ -550,44 +541,31 @@ func HandleCosmosHubLPCreated(w *Watcher, data coretypes.ResultEvent, chainName,
 	defer func() {
 		if err := w.store.SetComplete(key, height); err != nil {
 			w.l.Errorw("cannot set complete", "chain name", chainName, "error", err)
+			return
 		}
-	}()
 
-	chain, err := w.d.Chain(chainName)
-	if err != nil {
-		w.l.Errorw("can't find chain cosmos-hub", "error", err)
-		return
-	}
+		w.recordTxInfo(key, data)
+		w.recordTransition(key, chainName, height, data.Events)
+	}()
 
-	poolCoinDenom, ok := data.Events["create_pool.pool_coin_denom"]
-	if !ok {
-		w.l.Errorw("no field create_pool.pool_coin_denom in Events", "error", err)
+	if _, ok := data.Events["create_pool.pool_coin_denom"]; !ok {
+		w.l.Errorw("no field create_pool.pool_coin_denom in Events")
 		return
 	}
 
-	dd, err := formatDenom(w, data)
+	dd, lp, err := formatDenom(w, data)
 	if err != nil {
 		w.l.Errorw("failed to format denom", "error", err)
 		return
 	}
 
-	found := false
-	for _, token := range chain.Denoms {
-		if token.Name == poolCoinDenom[0] {
-			token = dd
-			found = true
-		}
-	}
-
-	if !found {
-		chain.Denoms = append(chain.Denoms, dd)
-	}
-
-	err = w.d.UpdateDenoms(chain)
-	if err != nil {
+	// replaces the pool denom if already listed, without clobbering concurrent updates of the other denoms
+	if err := w.d.UpsertDenom(chainName, dd); err != nil {
 		w.l.Errorw("failed to update chain", "error", err)
 		return
 	}
+
+	recordLPDenom(w, chainName, lp)
 }
 
 func HandleSwapTransaction(w *Watcher, data coretypes.ResultEvent, chainName, key string, height int64) {
@@ -613,20 +591,83 @@ func HandleSwapTransaction(w *Watcher, data coretypes.ResultEvent, chainName, ke
 		w.l.Errorw("unable to store swap fees", "error", err)
 	}
 
+	if !w.store.Exists(key) {
+		w.l.Debugw("bypassing key, event not sourced from us", "chain_name", w.Name, "key", key, "event", "swap")
+		return
+	}
+
+	if setBatched(w, data, chainName, key, liquiditytypes.EventTypeSwapWithinBatch, height) {
+		return
+	}
+
 	if err := w.store.SetComplete(key, height); err != nil {
 		w.l.Errorw("cannot set complete", "chain name", chainName, "error", err)
+		return
+	}
+
+	w.recordTxInfo(key, data)
+	w.recordTransition(key, chainName, height, data.Events)
+}
+
+// ibcApp describes an IBC application whose packets are tracked by the IBC handlers.
+type ibcApp struct {
+	// name prefixes the event names used in logs.
+	name string
+	// port is the port packets are sent from, and counterparties are looked up on.
+	port string
+	// isPort returns true if packets received from the given source port belong to the application.
+	isPort func(port string) bool
+	// ackError returns the error a packet was acknowledged with by the receiving chain, and false if
+	// the packet was acknowledged successfully.
+	ackError func(events map[string][]string) (string, bool)
+}
+
+var ics20App = ibcApp{
+	name:     "ibc",
+	port:     transferPort,
+	isPort:   isICS20Port,
+	ackError: packetAckError("fungible_token_packet"),
+}
+
+// packetAckError returns an ibcApp.ackError reading the error attribute of packetEvent, the event type
+// an application emits when processing packets.
+func packetAckError(packetEvent string) func(events map[string][]string) (string, bool) {
+	return func(events map[string][]string) (string, bool) {
+		packetErr, ok := events[packetEvent+".error"]
+		if !ok || len(packetErr) == 0 {
+			return "", false
+		}
+
+		return packetErr[0], true
 	}
 }
 
 func HandleIBCSenderEvent(w *Watcher, data coretypes.ResultEvent, chainName, txHash, key string, height int64) {
+	handleIBCSend(w, ics20App, data, chainName, txHash, key, height)
+}
+
+func HandleIBCReceivePacket(w *Watcher, data coretypes.ResultEvent, chainName, txHash string, height int64) {
+	handleIBCReceive(w, ics20App, data, chainName, txHash, height)
+}
+
+func HandleIBCTimeoutPacket(w *Watcher, data coretypes.ResultEvent, chainName, txHash string, height int64) {
+	handleIBCTimeout(w, ics20App, data, chainName, txHash, height)
+}
+
+func HandleIBCAckPacket(w *Watcher, data coretypes.ResultEvent, chainName, txHash string, height int64) {
+	handleIBCAck(w, ics20App, data, chainName, txHash, height)
+}
+
+func handleIBCSend(w *Watcher, app ibcApp, data coretypes.ResultEvent, chainName, txHash, key string, height int64) {
+	w.l.Debugw("called handleIBCSend", "app", app.name)
 	sendPacketSourcePort, ok := data.Events["send_packet.packet_src_port"]
 	if !ok {
 		w.l.Errorf("send_packet.packet_src_port not found")
 		return
 	}
 
-	if sendPacketSourcePort[0] != "transfer" {
-		w.l.Errorf("port is not 'transfer', ignoring")
+	if sendPacketSourcePort[0] != app.port {
+		w.l.Errorf("port is not '%s', ignoring", app.port)
 		return
 	}
 
@@ -642,28 +683,32 @@ func HandleIBCSenderEvent(w *Watcher, data coretypes.ResultEvent, chainName, txH
 		return
 	}
 
-	c, err := w.d.GetCounterParty(chainName, sendPacketSourceChannel[0])
+	counterparty, err := w.counterparty(chainName, app.port, sendPacketSourceChannel[0])
 	if err != nil {
-		w.l.Errorw("unable to fetch counterparty chain from db", "error", err)
+		w.l.Errorw("unable to fetch counterparty chain", "error", err)
 		return
 	}
 
-	if err := w.store.SetInTransit(key, c[0].Counterparty, sendPacketSourceChannel[0], sendPacketSequence[0],
+	if err := w.store.SetInTransit(key, counterparty, sendPacketSourceChannel[0], sendPacketSequence[0],
 		txHash, chainName, height); err != nil {
 		w.l.Errorw("unable to set status as in transit for key", "key", key, "error", err)
+		return
 	}
+
+	w.recordTxInfo(key, data)
+	w.recordTransition(key, chainName, height, data.Events)
 }
 
-func HandleIBCReceivePacket(w *Watcher, data coretypes.ResultEvent, chainName, txHash string, height int64) {
-	w.l.Debugw("called HandleIBCReceivePacket")
+func handleIBCReceive(w *Watcher, app ibcApp, data coretypes.ResultEvent, chainName, txHash string, height int64) {
+	w.l.Debugw("called handleIBCReceive", "app", app.name)
 	recvPacketSourcePort, ok := data.Events["recv_packet.packet_src_port"]
 	if !ok {
 		w.l.Errorf("recv_packet.packet_src_port not found")
 		return
 	}
 
-	if recvPacketSourcePort[0] != "transfer" {
-		w.l.Errorf("port is not 'transfer', ignoring")
+	if !app.isPort(recvPacketSourcePort[0]) {
+		w.l.Errorf("port %s does not carry %s packets, ignoring", recvPacketSourcePort[0], app.name)
 		return
 	}
 
@@ -687,7 +732,7 @@ func HandleIBCReceivePacket(w *Watcher, data coretypes.ResultEvent, chainName, t
 
 	key := store.GetIBCKey(chainName, recvPacketSourceChannel[0], recvPacketSequence[0])
 	if !w.store.Exists(key) {
-		w.l.Debugw("bypassing key, event not sourced from us", "chain_name", w.Name, "key", key, "event", "ibc_receive")
+		w.l.Debugw("bypassing key, event not sourced from us", "chain_name", w.Name, "key", key, "event", app.name+"_receive")
 		return
 	}
 
@@ -698,18 +743,37 @@ func HandleIBCReceivePacket(w *Watcher, data coretypes.ResultEvent, chainName, t
 	}
 
 	if ack.Result != ackSuccess {
-		if err := w.store.SetIbcFailed(key, txHash, chainName, height); err != nil {
+		err := keepIBCTicketFields(w.store, key, func() error {
+			return w.store.SetIbcFailed(key, txHash, chainName, height)
+		})
+		if err != nil {
 			w.l.Errorw("unable to set status as failed for key", "key", key, "error", err)
+			return
+		}
+
+		if ack.Error != "" {
+			if err := setIBCTicketError(w.store, key, ack.Error); err != nil {
+				w.l.Errorw("unable to set error acknowledgement for key", "key", key, "error", err)
+			}
 		}
+
+		w.recordIBCTransition(key, chainName, height, data.Events)
 		return
 	}
 
-	if err := w.store.SetIbcReceived(key, txHash, chainName, height); err != nil {
+	err := keepIBCTicketFields(w.store, key, func() error {
+		return w.store.SetIbcReceived(key, txHash, chainName, height)
+	})
+	if err != nil {
 		w.l.Errorw("unable to set status as ibc received for key", "key", key, "error", err)
+		return
 	}
+
+	w.recordIBCTransition(key, chainName, height, data.Events)
 }
 
-func HandleIBCTimeoutPacket(w *Watcher, data coretypes.ResultEvent, chainName, txHash string, height int64) {
+func handleIBCTimeout(w *Watcher, app ibcApp, data coretypes.ResultEvent, chainName, txHash string, height int64) {
+	w.l.Debugw("called handleIBCTimeout", "app", app.name)
 	timeoutPacketSourceChannel, ok := data.Events["timeout_packet.packet_src_channel"]
 	if !ok {
 		w.l.Errorf("timeout_packet.packet_src_channel not found")
@@ -722,25 +786,31 @@ func HandleIBCTimeoutPacket(w *Watcher, data coretypes.ResultEvent, chainName, t
 		return
 	}
 
-	c, err := w.d.GetCounterParty(chainName, timeoutPacketSourceChannel[0])
+	counterparty, err := w.counterparty(chainName, app.port, timeoutPacketSourceChannel[0])
 	if err != nil {
-		w.l.Errorw("unable to fetch counterparty chain from db", "error", err)
+		w.l.Errorw("unable to fetch counterparty chain", "error", err)
 		return
 	}
 
-	key := store.GetIBCKey(c[0].Counterparty, timeoutPacketSourceChannel[0], timeoutPacketSequence[0])
+	key := store.GetIBCKey(counterparty, timeoutPacketSourceChannel[0], timeoutPacketSequence[0])
 	if !w.store.Exists(key) {
-		w.l.Debugw("bypassing key, event not sourced from us", "chain_name", w.Name, "key", key, "event", "timeout")
+		w.l.Debugw("bypassing key, event not sourced from us", "chain_name", w.Name, "key", key, "event", app.name+"_timeout")
 		return
 	}
 
-	if err := w.store.SetIbcTimeoutUnlock(key, txHash, chainName, height); err != nil {
+	err = keepIBCTicketFields(w.store, key, func() error {
+		return w.store.SetIbcTimeoutUnlock(key, txHash, chainName, height)
+	})
+	if err != nil {
 		w.l.Errorw("unable to set status as ibc timeout unlock for key", "key", key, "error", err)
+		return
 	}
+
+	w.recordIBCTransition(key, chainName, height, data.Events)
 }
 
-func HandleIBCAckPacket(w *Watcher, data coretypes.ResultEvent, chainName, txHash string, height int64) {
-	w.l.Debugw("called HandleIBCAckPacket")
+func handleIBCAck(w *Watcher, app ibcApp, data coretypes.ResultEvent, chainName, txHash string, height int64) {
+	w.l.Debugw("called handleIBCAck", "app", app.name)
 	ackPacketSourceChannel, ok := data.Events["acknowledge_packet.packet_src_channel"]
 	if !ok {
 		w.l.Errorf("acknowledge_packet.packet_src_channel not found")
@@ -753,23 +823,37 @@ func HandleIBCAckPacket(w *Watcher, data coretypes.ResultEvent, chainName, txHas
 		return
 	}
 
-	c, err := w.d.GetCounterParty(chainName, ackPacketSourceChannel[0])
+	// successfully acknowledged packets were already accounted for when received
+	packetErr, failed := app.ackError(data.Events)
+	if !failed {
+		return
+	}
+
+	counterparty, err := w.counterparty(chainName, app.port, ackPacketSourceChannel[0])
 	if err != nil {
-		w.l.Errorw("unable to fetch counterparty chain from db", "error", err)
+		w.l.Errorw("unable to fetch counterparty chain", "error", err)
 		return
 	}
 
-	key := store.GetIBCKey(c[0].Counterparty, ackPacketSourceChannel[0], ackPacketSequence[0])
-	_, ok = data.Events["fungible_token_packet.error"]
-	if ok {
-		if !w.store.Exists(key) {
-			w.l.Debugw("bypassing key, event not sourced from us", "chain_name", w.Name, "key", key, "event", "ibc_ack")
-			return
-		}
+	key := store.GetIBCKey(counterparty, ackPacketSourceChannel[0], ackPacketSequence[0])
+	if !w.store.Exists(key) {
+		w.l.Debugw("bypassing key, event not sourced from us", "chain_name", w.Name, "key", key, "event", app.name+"_ack")
+		return
+	}
 
-		if err := w.store.SetIbcAckUnlock(key, txHash, chainName, height); err != nil {
-			w.l.Errorw("unable to set status as ibc ack unlock for key", "key", key, "error", err)
-		}
+	err = keepIBCTicketFields(w.store, key, func() error {
+		return w.store.SetIbcAckUnlock(key, txHash, chainName, height)
+	})
+	if err != nil {
+		w.l.Errorw("unable to set status as ibc ack unlock for key", "key", key, "error", err)
 		return
 	}
+
+	if packetErr != "" {
+		if err := setIBCTicketError(w.store, key, packetErr); err != nil {
+			w.l.Errorw("unable to set error acknowledgement for key", "key", key, "error", err)
+		}
+	}
+
+	w.recordIBCTransition(key, chainName, height, data.Events)
 }
@@ -5,7 +5,6 @@ import (
 	"testing"
 
 	"github.com/alicebob/miniredis/v2"
-	"github.com/cockroachdb/cockroach-go/v2/testserver"
 	"github.com/emerishq/emeris-rpcwatcher/rpcwatcher/database"
 	"github.com/emerishq/emeris-utils/logging"
 	"github.com/emerishq/emeris-utils/store"
@@ -17,17 +16,18 @@ import (
 // global variables for tests
 var (
 	s          *store.Store
-	dbInstance *database.Instance
+	dbInstance *database.FileRegistry
 	mr         *miniredis.Miniredis
 	logger     *zap.SugaredLogger
 )
 
-// TODO: use mocks for data in db
 func TestMain(m *testing.M) {
-	// setup test DB
-	var ts testserver.TestServer
-	ts, dbInstance = database.SetupTestDB(database.TestDBMigrations)
-	defer ts.Stop()
+	// setup test chain registry
+	var err error
+	dbInstance, err = database.NewRegistry([]byte(database.TestRegistry))
+	if err != nil {
+		panic(err)
+	}
 
 	// logger
 	logger = logging.New(logging.LoggingConfig{
@@ -43,8 +43,6 @@ func TestMain(m *testing.M) {
 }
 
 func TestHandleMessage(t *testing.T) {
-	defer store.ResetTestStore(mr, s)
-
 	tests := []struct {
 		name       string
 		event      coretypes.ResultEvent
@@ -143,7 +141,7 @@ func TestHandleMessage(t *testing.T) {
 			swapTransactionEvent(t),
 			logger,
 			swapTxHash,
-			"complete",
+			"batched",
 			func(t *testing.T, w *Watcher, data coretypes.ResultEvent, _ string) {
 				HandleMessage(w, data)
 			},
@@ -152,17 +150,18 @@ func TestHandleMessage(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			ms := NewMemoryStore()
 			watcherInstance := &Watcher{
 				l:     tt.logger,
 				d:     dbInstance,
-				store: s,
+				store: ms,
 				Name:  database.TestChainName,
 			}
-			require.NoError(t, s.CreateTicket(watcherInstance.Name, tt.txHash, testOwner))
+			require.NoError(t, ms.CreateTicket(watcherInstance.Name, tt.txHash, testOwner))
 			key := store.GetKey(database.TestChainName, tt.txHash)
 			tt.validateFn(t, watcherInstance, tt.event, key)
 			if tt.expStatus != "" {
-				ticket, err := s.Get(key)
+				ticket, err := ms.Get(key)
 				require.NoError(t, err)
 				require.Equal(t, tt.expStatus, ticket.Status)
 			}
@@ -177,7 +176,7 @@ func checkAndSetInTransit(t *testing.T, data coretypes.ResultEvent, w *Watcher,
 	require.True(t, ok)
 	require.GreaterOrEqual(t, 1, len(srcChannel))
 	require.GreaterOrEqual(t, 1, len(pktSeq))
-	require.NoError(t, s.SetInTransit(key, w.Name, srcChannel[0], pktSeq[0], txHash, w.Name, defaultHeight))
+	require.NoError(t, w.store.SetInTransit(key, w.Name, srcChannel[0], pktSeq[0], txHash, w.Name, defaultHeight))
 }
 
 func checkDenomExists(t *testing.T, w *Watcher, denom string, expected bool) {
@@ -195,17 +194,16 @@ func checkDenomExists(t *testing.T, w *Watcher, denom string, expected bool) {
 }
 
 func TestHandleCosmosHubLPCreated(t *testing.T) {
-	defer store.ResetTestStore(mr, s)
-
+	ms := NewMemoryStore()
 	watcherInstance := &Watcher{
 		l:     logger,
 		d:     dbInstance,
-		store: s,
+		store: ms,
 		Name:  database.TestChainName,
 	}
 
 	re := createPoolEvent(t, true)
-	require.NoError(t, s.CreateTicket(watcherInstance.Name, createPoolTxHash, testOwner))
+	require.NoError(t, ms.CreateTicket(watcherInstance.Name, createPoolTxHash, testOwner))
 	key := store.GetKey(database.TestChainName, createPoolTxHash)
 
 	tests := []struct {
@@ -248,14 +246,31 @@ func TestHandleCosmosHubLPCreated(t *testing.T) {
 			checkDenomExists(t, watcherInstance, newPoolDenom, tt.denomStored)
 		})
 	}
+
+	c, err := watcherInstance.d.Chain(watcherInstance.Name)
+	require.NoError(t, err)
+	for _, d := range c.Denoms {
+		if d.Name == newPoolDenom {
+			require.Equal(t, "ATOM/USD LP", d.DisplayName)
+			require.Equal(t, "ATOM/USD", d.Ticker)
+			require.Equal(t, int64(defaultLPDenomPrecision), d.Precision)
+		}
+	}
+
+	lp, err := LPDenomReserves(ms, watcherInstance.Name, newPoolDenom)
+	require.NoError(t, err)
+	require.Equal(t, LPDenom{
+		Denom:         newPoolDenom,
+		PoolID:        "1",
+		ReserveDenoms: []string{"uatom", "uusd"},
+	}, lp)
 }
 
 func TestHandleSwapTransaction(t *testing.T) {
 	watcherInstance := &Watcher{
-		l:     logger,
-		d:     dbInstance,
-		store: s,
-		Name:  database.TestChainName,
+		l:    logger,
+		d:    dbInstance,
+		Name: database.TestChainName,
 	}
 
 	re := swapTransactionEvent(t)
@@ -309,16 +324,17 @@ func TestHandleSwapTransaction(t *testing.T) {
 			"Handle swap transaction - valid data",
 			re,
 			defaultKey,
-			"complete",
+			"batched",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			defer store.ResetTestStore(mr, s)
-			require.NoError(t, s.CreateTicket(watcherInstance.Name, swapTxHash, testOwner))
+			ms := NewMemoryStore()
+			watcherInstance.store = ms
+			require.NoError(t, ms.CreateTicket(watcherInstance.Name, swapTxHash, testOwner))
 			HandleSwapTransaction(watcherInstance, tt.data, watcherInstance.Name, tt.key, defaultHeight)
-			ticket, err := s.Get(tt.key)
+			ticket, err := ms.Get(tt.key)
 			if tt.expStatus != "" {
 				require.NoError(t, err)
 			} else {
@@ -331,10 +347,9 @@ func TestHandleSwapTransaction(t *testing.T) {
 
 func TestHandleIBCSenderEvent(t *testing.T) {
 	watcherInstance := &Watcher{
-		l:     logger,
-		d:     dbInstance,
-		store: s,
-		Name:  database.TestChainName,
+		l:    logger,
+		d:    dbInstance,
+		Name: database.TestChainName,
 	}
 
 	re := ibcTransferEvent(t)
@@ -373,10 +388,11 @@ func TestHandleIBCSenderEvent(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			defer store.ResetTestStore(mr, s)
-			require.NoError(t, s.CreateTicket(watcherInstance.Name, ibcTransferTxHash, testOwner))
+			ms := NewMemoryStore()
+			watcherInstance.store = ms
+			require.NoError(t, ms.CreateTicket(watcherInstance.Name, ibcTransferTxHash, testOwner))
 			HandleIBCSenderEvent(watcherInstance, tt.data, watcherInstance.Name, ibcTransferTxHash, defaultKey, defaultHeight)
-			ticket, err := s.Get(defaultKey)
+			ticket, err := ms.Get(defaultKey)
 			require.NoError(t, err)
 			require.Equal(t, tt.expStatus, ticket.Status)
 		})
@@ -385,10 +401,9 @@ func TestHandleIBCSenderEvent(t *testing.T) {
 
 func TestHandleIBCReceivePktEvent(t *testing.T) {
 	watcherInstance := &Watcher{
-		l:     logger,
-		d:     dbInstance,
-		store: s,
-		Name:  database.TestChainName,
+		l:    logger,
+		d:    dbInstance,
+		Name: database.TestChainName,
 	}
 
 	eventWithSuccessAck := ibcReceivePacketEvent(t, true)
@@ -439,16 +454,17 @@ func TestHandleIBCReceivePktEvent(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			defer store.ResetTestStore(mr, s)
-			require.NoError(t, s.CreateTicket(watcherInstance.Name, ibcReceiveTxHash, testOwner))
+			ms := NewMemoryStore()
+			watcherInstance.store = ms
+			require.NoError(t, ms.CreateTicket(watcherInstance.Name, ibcReceiveTxHash, testOwner))
 			if tt.useDefault {
-				require.NoError(t, s.SetInTransit(defaultKey, watcherInstance.Name, defaultChannel, defaultPktSeq,
+				require.NoError(t, ms.SetInTransit(defaultKey, watcherInstance.Name, defaultChannel, defaultPktSeq,
 					ibcAckTxHash, watcherInstance.Name, defaultHeight))
 			} else {
 				checkAndSetInTransit(t, tt.data, watcherInstance, ibcReceiveTxHash, "recv_packet", defaultKey)
 			}
 			HandleIBCReceivePacket(watcherInstance, tt.data, watcherInstance.Name, ibcReceiveTxHash, defaultHeight)
-			ticket, err := s.Get(defaultKey)
+			ticket, err := ms.Get(defaultKey)
 			require.NoError(t, err)
 			require.Equal(t, tt.expStatus, ticket.Status)
 		})
@@ -457,10 +473,9 @@ func TestHandleIBCReceivePktEvent(t *testing.T) {
 
 func TestHandleIBCAckPktEvent(t *testing.T) {
 	watcherInstance := &Watcher{
-		l:     logger,
-		d:     dbInstance,
-		store: s,
-		Name:  database.TestChainName,
+		l:    logger,
+		d:    dbInstance,
+		Name: database.TestChainName,
 	}
 
 	// creating two receive tx events with different packet acknowledgements
@@ -504,16 +519,17 @@ func TestHandleIBCAckPktEvent(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			defer store.ResetTestStore(mr, s)
-			require.NoError(t, s.CreateTicket(watcherInstance.Name, ibcAckTxHash, testOwner))
+			ms := NewMemoryStore()
+			watcherInstance.store = ms
+			require.NoError(t, ms.CreateTicket(watcherInstance.Name, ibcAckTxHash, testOwner))
 			if tt.useDefault {
-				require.NoError(t, s.SetInTransit(defaultKey, watcherInstance.Name, defaultChannel, defaultPktSeq,
+				require.NoError(t, ms.SetInTransit(defaultKey, watcherInstance.Name, defaultChannel, defaultPktSeq,
 					ibcAckTxHash, watcherInstance.Name, defaultHeight))
 			} else {
 				checkAndSetInTransit(t, tt.data, watcherInstance, ibcAckTxHash, "acknowledge_packet", defaultKey)
 			}
 			HandleIBCAckPacket(watcherInstance, tt.data, watcherInstance.Name, ibcAckTxHash, defaultHeight)
-			ticket, err := s.Get(defaultKey)
+			ticket, err := ms.Get(defaultKey)
 			require.NoError(t, err)
 			require.Equal(t, tt.expStatus, ticket.Status)
 		})
@@ -522,10 +538,9 @@ func TestHandleIBCAckPktEvent(t *testing.T) {
 
 func TestHandleIBCTimeoutPktEvent(t *testing.T) {
 	watcherInstance := &Watcher{
-		l:     logger,
-		d:     dbInstance,
-		store: s,
-		Name:  database.TestChainName,
+		l:    logger,
+		d:    dbInstance,
+		Name: database.TestChainName,
 	}
 
 	re := ibcTimeoutEvent(t)
@@ -561,18 +576,70 @@ func TestHandleIBCTimeoutPktEvent(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			defer store.ResetTestStore(mr, s)
-			require.NoError(t, s.CreateTicket(watcherInstance.Name, ibcTimeoutTxHash, testOwner))
+			ms := NewMemoryStore()
+			watcherInstance.store = ms
+			require.NoError(t, ms.CreateTicket(watcherInstance.Name, ibcTimeoutTxHash, testOwner))
 			if tt.useDefault {
-				require.NoError(t, s.SetInTransit(defaultKey, watcherInstance.Name, defaultChannel, defaultPktSeq,
+				require.NoError(t, ms.SetInTransit(defaultKey, watcherInstance.Name, defaultChannel, defaultPktSeq,
 					ibcAckTxHash, watcherInstance.Name, defaultHeight))
 			} else {
 				checkAndSetInTransit(t, tt.data, watcherInstance, ibcTimeoutTxHash, "timeout_packet", defaultKey)
 			}
 			HandleIBCTimeoutPacket(watcherInstance, tt.data, watcherInstance.Name, ibcTimeoutTxHash, defaultHeight)
-			ticket, err := s.Get(defaultKey)
+			ticket, err := ms.Get(defaultKey)
+			require.NoError(t, err)
+			require.Equal(t, tt.expStatus, ticket.Status)
+		})
+	}
+}
+
+func TestHandleIBCErrorAcknowledgement(t *testing.T) {
+	watcherInstance := &Watcher{
+		l:    logger,
+		d:    dbInstance,
+		Name: database.TestChainName,
+	}
+
+	recvEvent := ibcReceivePacketEvent(t, false)
+	recvEvent.Events["write_acknowledgement.packet_ack"] = []string{`{"error":"` + defaultAckError + `"}`}
+	ackEvent := ibcAckTxEvent(t, true)
+	ackEvent.Events["fungible_token_packet.error"] = []string{defaultAckError}
+
+	tests := []struct {
+		name      string
+		data      coretypes.ResultEvent
+		txHash    string
+		eventType string
+		expStatus string
+	}{
+		{
+			"Handle ibc receive packet with error acknowledgement",
+			recvEvent,
+			ibcReceiveTxHash,
+			"recv_packet",
+			"IBC_receive_failed",
+		},
+		{
+			"Handle ibc ack packet with token packet error",
+			ackEvent,
+			ibcAckTxHash,
+			"acknowledge_packet",
+			"Tokens_unlocked_ack",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := NewMemoryStore()
+			watcherInstance.store = ms
+			require.NoError(t, ms.CreateTicket(watcherInstance.Name, tt.txHash, testOwner))
+			key := store.GetKey(database.TestChainName, tt.txHash)
+			checkAndSetInTransit(t, tt.data, watcherInstance, tt.txHash, tt.eventType, key)
+			HandleMessage(watcherInstance, tt.data)
+			ticket, err := ms.Get(key)
 			require.NoError(t, err)
 			require.Equal(t, tt.expStatus, ticket.Status)
+			require.Equal(t, defaultAckError, ticket.Error)
 		})
 	}
 }